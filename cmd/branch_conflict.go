@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jesusgpo/gh-buddy/internal/config"
+	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/prompt"
+	"github.com/jesusgpo/gh-buddy/internal/ui"
+)
+
+// branchConflict describes existing branches that clash with a new branch.
+type branchConflict struct {
+	name   string
+	local  bool
+	remote bool
+	// linked lists other branches already linked to the issue.
+	linked []string
+}
+
+// existing returns the branches that could be checked out instead of
+// creating a new one, the proposed name first.
+func (c *branchConflict) existing() []string {
+	var names []string
+	if c.local || c.remote {
+		names = append(names, c.name)
+	}
+	return append(names, c.linked...)
+}
+
+// findBranchConflict checks local and remote refs for name, given the
+// branches already linked to the issues. It returns nil when the name is
+// free and the issues have no linked branches.
+func findBranchConflict(name string, linked []string) *branchConflict {
+	c := &branchConflict{name: name, local: git.LocalBranchExists(name)}
+
+	remote, err := git.RemoteBranchExists(cfg.Remote, name)
+	if err != nil {
+		ui.Warning("Could not check for %q on %s: %v", name, cfg.Remote, err)
+	}
+	c.remote = remote
+
+	seen := map[string]bool{name: true}
+	for _, b := range linked {
+		if !seen[b] {
+			seen[b] = true
+			c.linked = append(c.linked, b)
+		}
+	}
+
+	if !c.local && !c.remote && len(c.linked) == 0 {
+		return nil
+	}
+	return c
+}
+
+// resolveBranchConflict asks the user how to handle the conflict, or applies
+// the configured policy in non-interactive mode. It returns the branch to use
+// and whether that branch already exists and should be checked out.
+func resolveBranchConflict(c *branchConflict) (string, bool, error) {
+	taken := c.local || c.remote
+	switch {
+	case c.local && c.remote:
+		ui.Warning("Branch %q already exists locally and on %s", c.name, cfg.Remote)
+	case c.local:
+		ui.Warning("Branch %q already exists locally", c.name)
+	case c.remote:
+		ui.Warning("Branch %q already exists on %s", c.name, cfg.Remote)
+	}
+	if len(c.linked) > 0 {
+		ui.Warning("The issue already has linked branches: %v", c.linked)
+	}
+
+	newName := c.name
+	if taken {
+		newName = nextFreeBranchName(c.name)
+	}
+
+	if useDefaults {
+		switch cfg.Branch.OnConflict {
+		case config.OnConflictCheckout:
+			return c.existing()[0], true, nil
+		case config.OnConflictSuffix:
+			return newName, false, nil
+		default:
+			return "", false, fmt.Errorf("a branch for this issue already exists; aborting (set branch.on_conflict to change this)")
+		}
+	}
+
+	existing := c.existing()
+	var options []string
+	for _, b := range existing {
+		options = append(options, fmt.Sprintf("Check out existing branch %q", b))
+	}
+	if taken {
+		options = append(options, fmt.Sprintf("Create %q instead", newName))
+	} else {
+		options = append(options, fmt.Sprintf("Create %q anyway", newName))
+	}
+	options = append(options, "Abort")
+
+	idx, err := prompt.Select("How do you want to continue?", options)
+	if err != nil {
+		return "", false, err
+	}
+	switch {
+	case idx < len(existing):
+		return existing[idx], true, nil
+	case idx == len(existing):
+		return newName, false, nil
+	default:
+		return "", false, fmt.Errorf("aborted: branch already exists")
+	}
+}
+
+// nextFreeBranchName appends the first numeric suffix (-2, -3, ...) that
+// yields a name free both locally and on the remote.
+func nextFreeBranchName(name string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if git.LocalBranchExists(candidate) {
+			continue
+		}
+		if remote, _ := git.RemoteBranchExists(cfg.Remote, candidate); remote {
+			continue
+		}
+		return candidate
+	}
+}
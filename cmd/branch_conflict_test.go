@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/config"
+)
+
+// setupBranches creates the local branches and, in a bare repository added
+// as origin, the remote ones.
+func setupBranches(t *testing.T, local, remote []string) {
+	t.Helper()
+	dir := setupRepo(t)
+	origin := filepath.Join(dir, "origin.git")
+	runGit(t, "init", "--quiet", "--bare", origin)
+	runGit(t, "remote", "add", "origin", origin)
+	for _, b := range remote {
+		runGit(t, "push", "--quiet", "origin", "HEAD:refs/heads/"+b)
+	}
+	for _, b := range local {
+		runGit(t, "branch", b)
+	}
+}
+
+func TestNextFreeBranchName(t *testing.T) {
+	tests := []struct {
+		name   string
+		local  []string
+		remote []string
+		want   string
+	}{
+		{name: "first suffix", local: []string{"feature/GH-1-x"}, want: "feature/GH-1-x-2"},
+		{name: "suffix taken locally", local: []string{"feature/GH-1-x", "feature/GH-1-x-2"}, want: "feature/GH-1-x-3"},
+		{name: "suffix taken on the remote", remote: []string{"feature/GH-1-x", "feature/GH-1-x-2"}, want: "feature/GH-1-x-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupBranches(t, tt.local, tt.remote)
+			if got := nextFreeBranchName("feature/GH-1-x"); got != tt.want {
+				t.Errorf("nextFreeBranchName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBranchConflict(t *testing.T) {
+	const name = "feature/GH-1-x"
+	tests := []struct {
+		name         string
+		policy       string
+		conflict     branchConflict
+		want         string
+		wantExisting bool
+		wantErr      bool
+	}{
+		{
+			name:         "checkout the taken name",
+			policy:       config.OnConflictCheckout,
+			conflict:     branchConflict{name: name, local: true, linked: []string{"feature/GH-1-old"}},
+			want:         name,
+			wantExisting: true,
+		},
+		{
+			name:         "checkout a linked branch",
+			policy:       config.OnConflictCheckout,
+			conflict:     branchConflict{name: name, linked: []string{"feature/GH-1-old"}},
+			want:         "feature/GH-1-old",
+			wantExisting: true,
+		},
+		{
+			name:     "suffix a taken name",
+			policy:   config.OnConflictSuffix,
+			conflict: branchConflict{name: name, local: true, remote: true},
+			want:     name + "-2",
+		},
+		{
+			name:     "suffix keeps a free name",
+			policy:   config.OnConflictSuffix,
+			conflict: branchConflict{name: name, linked: []string{"feature/GH-1-old"}},
+			want:     name,
+		},
+		{
+			name:     "abort",
+			policy:   config.OnConflictAbort,
+			conflict: branchConflict{name: name, remote: true},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var local, remote []string
+			if tt.conflict.local {
+				local = append(local, name)
+			}
+			if tt.conflict.remote {
+				remote = append(remote, name)
+			}
+			setupBranches(t, local, remote)
+			cfg.Branch.OnConflict = tt.policy
+
+			got, existing, err := resolveBranchConflict(&tt.conflict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveBranchConflict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || existing != tt.wantExisting {
+				t.Errorf("resolveBranchConflict() = %q, %v, want %q, %v", got, existing, tt.want, tt.wantExisting)
+			}
+		})
+	}
+}
+
+func TestFindBranchConflict(t *testing.T) {
+	setupBranches(t, []string{"feature/GH-1-local"}, []string{"feature/GH-1-remote"})
+
+	if c := findBranchConflict("feature/GH-1-free", nil); c != nil {
+		t.Errorf("findBranchConflict() of a free name = %+v, want nil", c)
+	}
+	c := findBranchConflict("feature/GH-1-local", []string{"feature/GH-1-local", "feature/GH-1-old"})
+	if c == nil || !c.local || c.remote || len(c.linked) != 1 {
+		t.Errorf("findBranchConflict() = %+v, want a local conflict with one other linked branch", c)
+	}
+	c = findBranchConflict("feature/GH-1-remote", nil)
+	if c == nil || c.local || !c.remote {
+		t.Errorf("findBranchConflict() = %+v, want a remote conflict", c)
+	}
+}
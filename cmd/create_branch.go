@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jesusgpo/gh-buddy/internal/branch"
 	"github.com/jesusgpo/gh-buddy/internal/ghapi"
 	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/issue"
 	"github.com/jesusgpo/gh-buddy/internal/prompt"
 	"github.com/jesusgpo/gh-buddy/internal/ui"
 	"github.com/spf13/cobra"
@@ -14,9 +16,12 @@ import (
 
 func newCreateBranchCmd() *cobra.Command {
 	var (
-		issueNumber int
-		issueType   string
-		baseBranch  string
+		issueRefs  []string
+		issueType  string
+		baseBranch string
+		title      string
+		worktree   string
+		filter     ghapi.IssueFilter
 	)
 
 	cmd := &cobra.Command{
@@ -25,7 +30,14 @@ func newCreateBranchCmd() *cobra.Command {
 		Long: `Create a local branch following naming conventions.
 
 If an issue number is provided, the branch name will be generated from the issue title.
-The branch type can be one of: feature, bugfix, hotfix, release, chore, docs, refactor, test, internal.`,
+Several issues can be given; the name then includes every issue number and the
+title of the first one.
+Issues from an external tracker (e.g. PAY-881) are supported when configured in
+.gh-buddy.yml; their title is taken from --title or prompted for.
+The branch type can be one of: feature, bugfix, hotfix, release, chore, docs, refactor, test, internal,
+or any type configured in .gh-buddy.yml.
+With --worktree the branch is checked out in a new worktree instead, leaving
+the current one untouched.`,
 		Example: `  # Create a branch from issue #42
   gh buddy create-branch --issue 42
 
@@ -35,52 +47,122 @@ The branch type can be one of: feature, bugfix, hotfix, release, chore, docs, re
   # Create a branch from a different base
   gh buddy create-branch --issue 42 --base develop
 
+  # Create a branch covering several issues
+  gh buddy create-branch --issue 12,15
+
+  # Create a branch from an issue of another repository
+  gh buddy create-branch --issue acme/product#123
+
+  # Create the branch in a new worktree, e.g. ../myrepo-GH-42
+  gh buddy create-branch --issue 42 --worktree
+  gh buddy create-branch --issue 42 --worktree=../review-42
+
+  # Create a branch from an external tracker issue
+  gh buddy create-branch --issue PAY-881 --title "Refund partial payments"
+
+  # Pick from unassigned bugs in a milestone
+  gh buddy create-branch --assignee none --label bug --milestone "Sprint 12"
+
   # Use defaults without prompts
   gh buddy create-branch --issue 42 -y`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && worktree == worktreeFromPattern {
+				return fmt.Errorf("unexpected argument %q; give the worktree path after an equals sign: --worktree=%s", args[0], args[0])
+			}
+			return cobra.NoArgs(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCreateBranch(issueNumber, issueType, baseBranch)
+			return runCreateBranch(issueRefs, issueType, baseBranch, title, worktree, filter)
 		},
 	}
 
-	cmd.Flags().IntVarP(&issueNumber, "issue", "i", 0, "issue number to create the branch from")
-	cmd.Flags().StringVarP(&issueType, "type", "t", "", "branch type (feature, bugfix, hotfix, release, chore, docs, refactor, test, internal)")
+	cmd.Flags().StringSliceVarP(&issueRefs, "issue", "i", nil, "issue numbers, keys, owner/repo#N references or URLs to create the branch from (comma-separated)")
+	cmd.Flags().StringVarP(&issueType, "type", "t", "", "branch type or alias (default: inferred from the issue labels)")
 	cmd.Flags().StringVarP(&baseBranch, "base", "b", "", "base branch to create from (default: repo default branch)")
+	cmd.Flags().StringVarP(&title, "title", "T", "", "title to generate the branch name from (default: the issue title)")
+	cmd.Flags().StringVar(&worktree, "worktree", "", "create the branch in a new worktree; give its path as --worktree=PATH (the = is required), or omit it to use worktree.path")
+	cmd.Flags().Lookup("worktree").NoOptDefVal = worktreeFromPattern
+	cmd.Flags().StringVar(&filter.Assignee, "assignee", "", `filter the issue picker by assignee: a login, "@me", "none" or "*" (default "@me")`)
+	cmd.Flags().StringSliceVarP(&filter.Labels, "label", "l", nil, "filter the issue picker by label")
+	cmd.Flags().StringVarP(&filter.Milestone, "milestone", "m", "", `filter the issue picker by milestone title, or "none"`)
+	cmd.Flags().StringVar(&filter.Project, "project", "", `filter the issue picker by project ("owner/number" or number)`)
+	cmd.Flags().StringVarP(&filter.Search, "search", "s", "", "filter the issue picker by text in the title or body")
+	cmd.Flags().IntVar(&filter.Limit, "limit", 0, "maximum number of issues to list in the picker (default 100)")
 
 	return cmd
 }
 
-func runCreateBranch(issueNumber int, issueType, baseBranch string) error {
-	repo, err := git.RepoSlug()
+func runCreateBranch(issueRefs []string, issueType, baseBranch, title, worktree string, filter ghapi.IssueFilter) error {
+	rc, err := currentRepo()
+	if err != nil {
+		return err
+	}
+	repo := rc.upstream.Slug()
+
+	// If no issue provided, prompt for selection or manual input
+	var keys []issue.Key
+	if len(issueRefs) == 0 {
+		keys, err = promptForIssues(rc.upstream, withFilterDefaults(filter))
+	} else {
+		keys, err = parseIssueKeys(issueRefs, rc.upstream)
+	}
 	if err != nil {
-		return fmt.Errorf("not in a git repository or no origin remote: %w", err)
+		return err
 	}
 
-	// If no issue number provided, prompt for selection or manual input
-	if issueNumber == 0 {
-		issueNumber, err = promptForIssue(repo)
+	// Fetch issue details; issues from external trackers only have a key
+	var labels, linked []string
+	var milestone, login, repoDefault string
+	for i, key := range keys {
+		if !key.IsGitHub() {
+			ui.IssuePanel(key.String(), "")
+			continue
+		}
+		details, err := client.GetIssueContext(issueRepo(key, repo), key.Number())
 		if err != nil {
 			return err
 		}
+		showIssueContext(key, details)
+		labels = append(labels, details.LabelNames()...)
+		linked = append(linked, details.LinkedBranches...)
+		login = details.Viewer
+		if key.Repo() == "" && repoDefault == "" {
+			repoDefault = details.DefaultBranch
+		}
+		if i == 0 {
+			milestone = details.MilestoneTitle()
+			if title == "" {
+				title = details.Title
+			}
+		}
 	}
-
-	// Fetch issue details
-	issue, err := ghapi.GetIssue(repo, issueNumber)
-	if err != nil {
-		return err
+	if title == "" {
+		if useDefaults {
+			return fmt.Errorf("issue %s is tracked in %s; pass --title to name the branch", keys[0], keys[0].Tracker)
+		}
+		title = prompt.Input(fmt.Sprintf("Title for %s", keys[0]), "")
 	}
 
-	ui.IssuePanel(issue.Number, issue.Title)
-
 	// Determine issue type
-	if issueType == "" {
-		issueType = inferIssueType(issue)
+	var selectedType branch.IssueType
+	if issueType != "" {
+		t, ok := naming.Types.Resolve(issueType)
+		if !ok {
+			return fmt.Errorf("invalid branch type %q. Valid types: %v", issueType, naming.Types.AllIssueTypeStrings())
+		}
+		selectedType = t
+	} else {
+		selectedType = naming.Types.Infer(labels)
+	}
+	if selectedType == "" {
+		selectedType = naming.DefaultType
 	}
 
 	if !useDefaults {
-		types := branch.AllIssueTypeStrings()
+		types := naming.Types.AllIssueTypeStrings()
 		defaultIdx := 0
 		for i, t := range types {
-			if t == issueType {
+			if t == string(selectedType) {
 				defaultIdx = i
 				break
 			}
@@ -89,21 +171,12 @@ func runCreateBranch(issueNumber int, issueType, baseBranch string) error {
 		if err != nil {
 			return err
 		}
-		issueType = types[idx]
-	} else if issueType == "" {
-		issueType = "feature"
-	}
-
-	if !branch.ValidIssueType(issueType) {
-		return fmt.Errorf("invalid branch type %q. Valid types: %v", issueType, branch.AllIssueTypeStrings())
+		selectedType = branch.IssueType(types[idx])
 	}
 
 	// Determine base branch
 	if baseBranch == "" {
-		defaultBase, err := git.DefaultBranch()
-		if err != nil {
-			defaultBase = "main"
-		}
+		defaultBase := defaultBaseBranch(rc.baseRemote, repoDefault)
 		if !useDefaults {
 			baseBranch = prompt.Input("Base branch", defaultBase)
 		} else {
@@ -112,123 +185,329 @@ func runCreateBranch(issueNumber int, issueType, baseBranch string) error {
 	}
 
 	// Generate branch name
-	branchName := branch.GenerateName(branch.IssueType(issueType), issueNumber, issue.Title)
+	fields := branch.Fields{
+		Type:      selectedType,
+		Keys:      keys,
+		Title:     title,
+		Milestone: milestone,
+		Date:      time.Now(),
+	}
+	if naming.Format.Uses("login") {
+		if login == "" {
+			login, err = client.CurrentUser()
+			if err != nil {
+				return err
+			}
+		}
+		fields.Login = login
+	}
+	branchName := naming.GenerateName(fields)
 
-	if !useDefaults {
-		branchName = prompt.Input("Branch name", branchName)
+	// Settle on a valid name that is free, or on an existing branch. A name
+	// picked to avoid a conflict goes through the same checks again.
+	checkout := false
+	for {
+		if !useDefaults {
+			branchName = prompt.Input("Branch name", branchName)
+		}
+		if err := naming.Validate(branchName); err != nil {
+			if useDefaults {
+				return err
+			}
+			ui.Warning("%v", err)
+			continue
+		}
+
+		conflict := findBranchConflict(branchName, linked)
+		if conflict == nil {
+			break
+		}
+		name, existing, err := resolveBranchConflict(conflict)
+		if err != nil {
+			return err
+		}
+		branchName = name
+		if existing {
+			checkout = true
+			break
+		}
+		// The user chose a new branch despite the linked ones
+		linked = nil
+	}
+
+	if checkout && worktree != "" {
+		return createWorktree(worktree, keys, branchName, func(dir string) error {
+			return git.AddWorktreeForBranch(dir, branchName, cfg.Remote)
+		})
+	}
+	if checkout {
+		err := switchWithChanges(branchName, func() error {
+			return git.CheckoutBranch(branchName, cfg.Remote)
+		})
+		if err != nil {
+			return err
+		}
+		ui.Success("Checked out existing branch %q", branchName)
+		return nil
 	}
 
 	ui.BranchPanel(branchName, baseBranch)
 
-	// Create the branch
-	if err := git.CreateBranchFrom(branchName, baseBranch, "origin"); err != nil {
-		return err
+	// Create the branch, in a new worktree or in this one
+	if worktree != "" {
+		err = createWorktree(worktree, keys, branchName, func(dir string) error {
+			return git.AddWorktree(dir, branchName, baseBranch, rc.baseRemote)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		err = switchWithChanges(branchName, func() error {
+			return git.CreateBranchFrom(branchName, baseBranch, rc.baseRemote)
+		})
+		if err != nil {
+			return err
+		}
+		ui.Success("Branch %q created and checked out successfully!", branchName)
 	}
-
-	ui.Success("Branch %q created and checked out successfully!", branchName)
+	recordBranchIssues(branchName, keys)
 
 	// Ask to push
-	shouldPush := useDefaults || prompt.Confirm("Push branch to origin?", true)
+	shouldPush := useDefaults || prompt.Confirm(fmt.Sprintf("Push branch to %s?", cfg.Remote), true)
 	if shouldPush {
-		// Use `gh issue develop` to push the branch to GitHub and link it to the
-		// issue in one step. If that fails, fall back to a regular git push.
-		if linkErr := ghapi.LinkBranchToIssue(repo, issueNumber, branchName, baseBranch); linkErr != nil {
-			ui.Warning("Could not create linked branch via gh issue develop (%v), falling back to git push", linkErr)
-			if err := git.PushBranch("origin", branchName); err != nil {
-				return err
-			}
-			ui.Success("Branch pushed to origin")
-		} else {
+		return pushAndLinkBranch(rc, keys, branchName, baseBranch)
+	}
+
+	return nil
+}
+
+// pushAndLinkBranch pushes the new branch and links it to its GitHub issues.
+// The first link uses `gh issue develop` (or the createLinkedBranch
+// mutation), which creates the branch on GitHub and links it in one step; if
+// that fails, it falls back to a regular git push. Linking the other issues
+// is best effort: GitHub may refuse to link a branch that already exists, in
+// which case create-pr closes them from the pull request instead. For forks
+// the branch is created in the fork and linked to the upstream issues.
+func pushAndLinkBranch(rc repoContext, keys []issue.Key, branchName, baseBranch string) error {
+	var github []issue.Key
+	for _, key := range keys {
+		if key.IsGitHub() {
+			github = append(github, key)
+		}
+	}
+	link := func(key issue.Key) error {
+		return client.LinkBranchToIssue(issueRepo(key, rc.upstream.Slug()), key.Number(), rc.origin.Slug(), branchName, baseBranch)
+	}
+
+	pushed := false
+	if len(github) > 0 {
+		err := link(github[0])
+		if err == nil {
 			// Branch now exists on remote; configure local tracking
-			if err := git.SetUpstreamTracking("origin", branchName); err != nil {
-				ui.Warning("Branch pushed but could not set upstream tracking: %v", err)
+			if err := git.SetUpstreamTracking(cfg.Remote, branchName); err != nil {
+				ui.Warning("Branch created on GitHub but could not set upstream tracking: %v", err)
 			}
-			ui.Success("Branch pushed to origin")
-			ui.Success("Branch linked to issue #%d", issueNumber)
+			ui.Success("Branch created on GitHub and linked to issue %s", github[0])
+			pushed = true
+		} else {
+			ui.Warning("Could not create linked branch via gh issue develop (%v), falling back to git push", err)
+			showHint(err)
+		}
+	}
+
+	if !pushed {
+		if err := git.PushBranch(cfg.Remote, branchName); err != nil {
+			return err
 		}
+		ui.Success("Branch pushed to %s", cfg.Remote)
 	}
 
+	for i := 1; i < len(github); i++ {
+		if err := link(github[i]); err != nil {
+			ui.Info("Could not link the branch to issue %s (%v); the pull request will close it", github[i], err)
+			continue
+		}
+		ui.Success("Branch linked to issue %s", github[i])
+	}
 	return nil
 }
 
-func promptForIssue(repo string) (int, error) {
-	// List open issues assigned to the user
-	issues, err := ghapi.ListOpenIssues(repo)
-	if err != nil {
-		// Fallback to manual input
-		input := prompt.Input("Issue number", "")
-		num, err := strconv.Atoi(input)
-		if err != nil {
-			return 0, fmt.Errorf("invalid issue number: %s", input)
+// branchIssuesKey is the git config key, under branch.<name>, that records
+// the issues a branch was created for.
+const branchIssuesKey = "buddy-issues"
+
+// showIssueContext prints an issue with the related work already under way.
+func showIssueContext(key issue.Key, details *ghapi.IssueContext) {
+	ui.IssuePanel(key.String(), details.Title)
+	if len(details.Assignees) > 0 {
+		ui.Info("Assigned to: %s", strings.Join(details.Assignees, ", "))
+	}
+	if details.Parent != nil {
+		ui.Info("Sub-issue of %s: %s", details.Parent, details.Parent.Title)
+	}
+	if len(details.SubIssues) > 0 {
+		subs := make([]string, len(details.SubIssues))
+		for i, sub := range details.SubIssues {
+			subs[i] = fmt.Sprintf("%s (%s)", sub, strings.ToLower(sub.State))
 		}
-		return num, nil
+		ui.Info("Sub-issues: %s", strings.Join(subs, ", "))
+	}
+	if len(details.Projects) > 0 {
+		ui.Info("Projects: %s", strings.Join(details.Projects, ", "))
 	}
+	for _, pr := range details.LinkedPRs {
+		ui.Warning("Issue %s already has an open pull request: %s", key, pr.URL)
+	}
+}
 
-	if len(issues) == 0 {
-		input := prompt.Input("No issues assigned to you. Enter issue number", "")
-		num, err := strconv.Atoi(input)
-		if err != nil {
-			return 0, fmt.Errorf("invalid issue number: %s", input)
+// issueRepo returns the "owner/repo" a GitHub issue key belongs to, given
+// the current repository.
+func issueRepo(k issue.Key, repo string) string {
+	if r := k.Repo(); r != "" {
+		return r
+	}
+	return repo
+}
+
+// recordBranchIssues stores the branch's issues in git config so create-pr
+// does not have to guess them from the branch name.
+func recordBranchIssues(branchName string, keys []issue.Key) {
+	refs := make([]string, len(keys))
+	for i, k := range keys {
+		refs[i] = k.String()
+	}
+	if err := git.SetBranchConfig(branchName, branchIssuesKey, strings.Join(refs, ",")); err != nil {
+		ui.Warning("Could not record the branch's issues: %v", err)
+	}
+}
+
+// parseIssueKeys parses issue references, accepting comma-separated lists
+// and dropping duplicates. References to issues of repo itself are
+// normalised to plain GitHub keys. Issue URLs must be on repo's host, which
+// is the only one the client talks to.
+func parseIssueKeys(refs []string, repo git.Repo) ([]issue.Key, error) {
+	var keys []issue.Key
+	seen := make(map[issue.Key]bool)
+	for _, ref := range refs {
+		for _, part := range strings.Split(ref, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			key, err := naming.Keys.ParseKey(part)
+			if err != nil {
+				return nil, err
+			}
+			if key.Host != "" && !strings.EqualFold(key.Host, repo.Host) {
+				return nil, fmt.Errorf("issue %s is on %s, but this repository is on %s", strings.TrimSpace(part), key.Host, repo.Host)
+			}
+			key.Host = ""
+			if strings.EqualFold(key.Repo(), repo.Slug()) {
+				key.Project = ""
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
 		}
-		return num, nil
 	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no issue given")
+	}
+	return keys, nil
+}
 
-	options := make([]string, len(issues))
-	for i, issue := range issues {
-		options[i] = fmt.Sprintf("#%d - %s", issue.Number, issue.Title)
+func promptForIssues(repo git.Repo, filter ghapi.IssueFilter) ([]issue.Key, error) {
+	if !naming.Keys.IsGitHub() {
+		input := prompt.Input("Issue key(s)", "")
+		return parseIssueKeys([]string{input}, repo)
 	}
 
-	idx, err := prompt.Select("Select an issue:", options)
+	// List open issues matching the filter
+	list, err := client.ListIssues(repo.Slug(), filter)
 	if err != nil {
-		return 0, err
+		ui.Warning("Could not list issues: %v", err)
+		showHint(err)
+		// Fallback to manual input
+		input := prompt.Input("Issue number(s)", "")
+		return parseIssueKeys([]string{input}, repo)
+	}
+
+	if len(list.Issues) == 0 {
+		input := prompt.Input("No issues match the filters. Enter issue number(s)", "")
+		return parseIssueKeys([]string{input}, repo)
+	}
+	if list.Truncated {
+		ui.Warning("Showing the first %d issues; narrow the filters or raise --limit to see more", len(list.Issues))
 	}
 
-	return issues[idx].Number, nil
+	options := make([]string, len(list.Issues))
+	for i, issue := range list.Issues {
+		options[i] = issueOption(issue)
+	}
+
+	selected, err := prompt.MultiSelect("Select one or more issues:", options)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no issue selected")
+	}
+
+	keys := make([]issue.Key, len(selected))
+	for i, idx := range selected {
+		keys[i] = issue.GitHubKey(list.Issues[idx].Number)
+	}
+	return keys, nil
 }
 
-func inferIssueType(issue *ghapi.Issue) string {
-	for _, label := range issue.Labels {
-		name := label.Name
-		switch {
-		case contains(name, "bug", "fix"):
-			return "bugfix"
-		case contains(name, "feature", "enhancement"):
-			return "feature"
-		case contains(name, "hotfix", "urgent", "critical"):
-			return "hotfix"
-		case contains(name, "docs", "documentation"):
-			return "docs"
-		case contains(name, "refactor"):
-			return "refactor"
-		case contains(name, "test"):
-			return "test"
-		case contains(name, "chore", "maintenance"):
-			return "chore"
-		case contains(name, "internal"):
-			return "internal"
-		}
-	}
-	return ""
+// issueOption formats an issue for the picker, with its labels and milestone.
+func issueOption(i ghapi.Issue) string {
+	option := fmt.Sprintf("#%d - %s", i.Number, i.Title)
+	if labels := i.LabelNames(); len(labels) > 0 {
+		option += fmt.Sprintf(" [%s]", strings.Join(labels, ", "))
+	}
+	if milestone := i.MilestoneTitle(); milestone != "" {
+		option += fmt.Sprintf(" (%s)", milestone)
+	}
+	return option
 }
 
-func contains(s string, substrs ...string) bool {
-	s = toLower(s)
-	for _, sub := range substrs {
-		if s == sub || len(s) > len(sub) && (s[:len(sub)] == sub || s[len(s)-len(sub):] == sub) {
-			return true
-		}
+// withFilterDefaults fills the filters not given as flags from the config.
+func withFilterDefaults(f ghapi.IssueFilter) ghapi.IssueFilter {
+	if f.Assignee == "" {
+		f.Assignee = cfg.Issues.Assignee
+	}
+	if len(f.Labels) == 0 {
+		f.Labels = cfg.Issues.Labels
+	}
+	if f.Milestone == "" {
+		f.Milestone = cfg.Issues.Milestone
 	}
-	return false
+	if f.Project == "" {
+		f.Project = cfg.Issues.Project
+	}
+	if f.Search == "" {
+		f.Search = cfg.Issues.Search
+	}
+	if f.Limit == 0 {
+		f.Limit = cfg.Issues.Limit
+	}
+	return f
 }
 
-func toLower(s string) string {
-	b := make([]byte, len(s))
-	for i := range s {
-		c := s[i]
-		if c >= 'A' && c <= 'Z' {
-			c += 'a' - 'A'
-		}
-		b[i] = c
+// defaultBaseBranch returns the configured base branch. Otherwise it returns
+// repoDefault, the default branch GitHub reported along with the issues, or
+// when no issue was fetched, the default branch of the remote.
+func defaultBaseBranch(remote, repoDefault string) string {
+	if cfg.Base != "" {
+		return cfg.Base
+	}
+	if repoDefault != "" {
+		return repoDefault
+	}
+	base, err := git.DefaultBranch(remote)
+	if err != nil {
+		return cfg.FallbackBase
 	}
-	return string(b)
+	return base
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import "testing"
+
+func TestDefaultBaseBranch(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		repoDefault string
+		// remoteHead is the branch origin/HEAD points at; empty means none
+		remoteHead string
+		want       string
+	}{
+		{name: "configured", base: "develop", repoDefault: "main", remoteHead: "main", want: "develop"},
+		{name: "reported with the issue", repoDefault: "trunk", remoteHead: "main", want: "trunk"},
+		{name: "remote HEAD", remoteHead: "stable", want: "stable"},
+		{name: "fallback", want: "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRepo(t)
+			if tt.remoteHead != "" {
+				runGit(t, "update-ref", "refs/remotes/origin/"+tt.remoteHead, "HEAD")
+				runGit(t, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/"+tt.remoteHead)
+			}
+			cfg.Base = tt.base
+			cfg.FallbackBase = "fallback"
+
+			if got := defaultBaseBranch("origin", tt.repoDefault); got != tt.want {
+				t.Errorf("defaultBaseBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
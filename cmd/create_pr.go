@@ -2,12 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/jesusgpo/gh-buddy/internal/ghapi"
 	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/issue"
 	"github.com/jesusgpo/gh-buddy/internal/prompt"
 	"github.com/jesusgpo/gh-buddy/internal/ui"
 	"github.com/spf13/cobra"
@@ -15,12 +14,12 @@ import (
 
 func newCreatePRCmd() *cobra.Command {
 	var (
-		issueNumber int
-		baseBranch  string
-		title       string
-		body        string
-		draft       bool
-		labels      []string
+		issueRefs  []string
+		baseBranch string
+		title      string
+		body       string
+		draft      bool
+		labels     []string
 	)
 
 	cmd := &cobra.Command{
@@ -28,15 +27,24 @@ func newCreatePRCmd() *cobra.Command {
 		Short: "Create a pull request from the current local branch",
 		Long: `Create a pull request from the current branch.
 
-If an issue number is detected from the branch name or provided explicitly, the PR 
-title and body will be pre-populated from the issue. Supports linking issues 
-automatically via "Closes #N" in the PR body.`,
+If issue numbers are detected from the branch or provided explicitly, the PR 
+title and body will be pre-populated from the issues. Supports linking issues 
+automatically via "Closes #N" in the PR body. Issues from an external tracker
+(e.g. PAY-881) are referenced in the title and body instead.
+
+The branch is compared with the base and with its copy on the remote first.
+A branch without commits over the base is refused, and it is only pushed
+when the remote lacks some of its commits. If it has diverged, e.g. after a
+rebase, you are asked before force-pushing with --force-with-lease.`,
 		Example: `  # Create a PR from the current branch (auto-detect issue)
   gh buddy create-pr
 
   # Create a PR linked to a specific issue
   gh buddy create-pr --issue 42
 
+  # Create a PR closing several issues
+  gh buddy create-pr --issue 12,15
+
   # Create a draft PR
   gh buddy create-pr --draft
 
@@ -46,11 +54,11 @@ automatically via "Closes #N" in the PR body.`,
   # Use defaults without prompts
   gh buddy create-pr -y`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCreatePR(issueNumber, baseBranch, title, body, draft, labels)
+			return runCreatePR(issueRefs, baseBranch, title, body, draft, labels)
 		},
 	}
 
-	cmd.Flags().IntVarP(&issueNumber, "issue", "i", 0, "issue number to link the PR to")
+	cmd.Flags().StringSliceVarP(&issueRefs, "issue", "i", nil, "issue numbers, keys, owner/repo#N references or URLs to link the PR to (comma-separated)")
 	cmd.Flags().StringVarP(&baseBranch, "base", "b", "", "base branch for the PR (default: repo default branch)")
 	cmd.Flags().StringVarP(&title, "title", "T", "", "PR title (default: generated from issue or branch)")
 	cmd.Flags().StringVar(&body, "body", "", "PR body")
@@ -60,11 +68,12 @@ automatically via "Closes #N" in the PR body.`,
 	return cmd
 }
 
-func runCreatePR(issueNumber int, baseBranch, title, body string, draft bool, labels []string) error {
-	repo, err := git.RepoSlug()
+func runCreatePR(issueRefs []string, baseBranch, title, body string, draft bool, labels []string) error {
+	rc, err := currentRepo()
 	if err != nil {
-		return fmt.Errorf("not in a git repository or no origin remote: %w", err)
+		return err
 	}
+	repo := rc.upstream.Slug()
 
 	currentBranch, err := git.CurrentBranch()
 	if err != nil {
@@ -73,28 +82,42 @@ func runCreatePR(issueNumber int, baseBranch, title, body string, draft bool, la
 
 	ui.Info("Current branch: %s", currentBranch)
 
-	// Try to detect issue number from branch name
-	if issueNumber == 0 {
-		issueNumber = extractIssueFromBranch(currentBranch)
+	// Use the given issues, or detect them from the branch
+	var keys []issue.Key
+	if len(issueRefs) > 0 {
+		keys, err = parseIssueKeys(issueRefs, rc.upstream)
+		if err != nil {
+			return err
+		}
+	} else {
+		keys = confirmUnsureIssues(branchIssues(currentBranch, rc.upstream))
 	}
 
-	// Fetch issue details if we have a number
-	var issue *ghapi.Issue
-	if issueNumber > 0 {
-		issue, err = ghapi.GetIssue(repo, issueNumber)
+	// Fetch details of GitHub issues
+	var repoDefault string
+	issues := make([]linkedIssue, len(keys))
+	for i, key := range keys {
+		issues[i].key = key
+		if !key.IsGitHub() {
+			ui.IssuePanel(key.String(), "")
+			continue
+		}
+		details, err := client.GetIssueContext(issueRepo(key, repo), key.Number())
 		if err != nil {
-			ui.Warning("Could not fetch issue #%d: %v", issueNumber, err)
-		} else {
-			ui.IssuePanel(issue.Number, issue.Title)
+			ui.Warning("Could not fetch issue %s: %v", key, err)
+			showHint(err)
+			continue
+		}
+		issues[i].details = details
+		if key.Repo() == "" && repoDefault == "" {
+			repoDefault = details.DefaultBranch
 		}
+		showIssueContext(key, details)
 	}
 
 	// Determine base branch
 	if baseBranch == "" {
-		defaultBase, err := git.DefaultBranch()
-		if err != nil {
-			defaultBase = "main"
-		}
+		defaultBase := defaultBaseBranch(rc.baseRemote, repoDefault)
 		if !useDefaults {
 			baseBranch = prompt.Input("Base branch", defaultBase)
 		} else {
@@ -102,11 +125,20 @@ func runCreatePR(issueNumber int, baseBranch, title, body string, draft bool, la
 		}
 	}
 
+	// Check there is something to propose and what pushing it takes
+	action, err := planPush(rc, currentBranch, baseBranch)
+	if err != nil {
+		return err
+	}
+
 	// Generate title
 	if title == "" {
-		if issue != nil {
-			title = issue.Title
-		} else {
+		switch {
+		case len(issues) > 0 && issues[0].details != nil:
+			title = issues[0].details.Title
+		case len(issues) > 0 && !issues[0].key.IsGitHub():
+			title = fmt.Sprintf("%s: %s", issues[0].key, generateTitleFromBranch(currentBranch))
+		default:
 			title = generateTitleFromBranch(currentBranch)
 		}
 		if !useDefaults {
@@ -116,7 +148,7 @@ func runCreatePR(issueNumber int, baseBranch, title, body string, draft bool, la
 
 	// Generate body
 	if body == "" {
-		body = generatePRBody(issue)
+		body = generatePRBody(issues)
 		if !useDefaults {
 			ui.BodyPreview(body)
 			if !prompt.Confirm("Use this PR body?", true) {
@@ -139,17 +171,12 @@ func runCreatePR(issueNumber int, baseBranch, title, body string, draft bool, la
 		}
 	}
 
-	// Push the branch first
-	spinner, _ := ui.StartSpinner("Pushing branch to origin...")
-	pushErr := git.PushBranch("origin", currentBranch)
-	if pushErr != nil {
-		// Branch might already be pushed, continue
-		spinner.Fail(fmt.Sprintf("Push warning: %v (continuing anyway)", pushErr))
-	} else {
-		spinner.Success("Branch pushed to origin")
+	// Push the branch first, unless the remote already has every commit
+	if err := pushBranch(action, currentBranch); err != nil {
+		return err
 	}
 
-	pr, err := ghapi.CreatePR(repo, title, body, baseBranch, currentBranch, draft, labels)
+	pr, err := client.CreatePR(repo, title, body, baseBranch, rc.head(currentBranch), draft, labels)
 	if err != nil {
 		return err
 	}
@@ -158,28 +185,68 @@ func runCreatePR(issueNumber int, baseBranch, title, body string, draft bool, la
 	return nil
 }
 
-var issueNumberRegex = regexp.MustCompile(`/GH-(\d+)-`)
+// linkedIssue is an issue a PR refers to. details is nil for issues from
+// external trackers and GitHub issues that could not be fetched.
+type linkedIssue struct {
+	key     issue.Key
+	details *ghapi.IssueContext
+}
 
-func extractIssueFromBranch(branchName string) int {
-	matches := issueNumberRegex.FindStringSubmatch(branchName)
-	if len(matches) >= 2 {
-		num, err := strconv.Atoi(matches[1])
-		if err == nil {
-			return num
+// branchIssues returns the issues recorded for the branch by create-branch,
+// or else the first issue parsed from its name. A name cannot tell a second
+// issue number from a title starting with digits, as in
+// "bugfix/GH-42-404-page-shows-blank", so the other issues it seems to name
+// are returned apart, as unsure, for the caller to confirm.
+func branchIssues(branchName string, repo git.Repo) (keys, unsure []issue.Key) {
+	if recorded := git.BranchConfig(branchName, branchIssuesKey); recorded != "" {
+		if keys, err := parseIssueKeys([]string{recorded}, repo); err == nil {
+			return keys, nil
 		}
 	}
-	return 0
+	keys = extractIssueFromBranch(branchName)
+	if len(keys) > 1 {
+		return keys[:1], keys[1:]
+	}
+	return keys, nil
+}
+
+func extractIssueFromBranch(branchName string) []issue.Key {
+	parsed, ok := naming.Parse(branchName)
+	if !ok {
+		return nil
+	}
+	return parsed.Keys
+}
+
+// confirmUnsureIssues returns keys followed by the unsure ones the user
+// confirms. In non-interactive mode only keys are used.
+func confirmUnsureIssues(keys, unsure []issue.Key) []issue.Key {
+	if len(unsure) == 0 {
+		return keys
+	}
+	refs := make([]string, len(unsure))
+	for i, k := range unsure {
+		refs[i] = k.String()
+	}
+	list := strings.Join(refs, ", ")
+	if useDefaults {
+		ui.Info("Linking %s only; the branch name may also refer to %s, pass --issue to link them", keys[0], list)
+		return keys
+	}
+	if prompt.Confirm(fmt.Sprintf("The branch name may also refer to %s. Link the pull request to them too?", list), false) {
+		return append(keys, unsure...)
+	}
+	return keys
 }
 
 func generateTitleFromBranch(branchName string) string {
-	// Remove type prefix (e.g., "feature/")
-	parts := strings.SplitN(branchName, "/", 2)
 	title := branchName
-	if len(parts) == 2 {
+	if parsed, ok := naming.Parse(branchName); ok && parsed.Slug != "" {
+		title = parsed.Slug
+	} else if parts := strings.SplitN(branchName, "/", 2); len(parts) == 2 {
+		// Remove type prefix (e.g., "feature/")
 		title = parts[1]
 	}
-	// Remove issue number prefix
-	title = regexp.MustCompile(`^\d+-`).ReplaceAllString(title, "")
 	// Replace hyphens with spaces and capitalize
 	title = strings.ReplaceAll(title, "-", " ")
 	if len(title) > 0 {
@@ -188,25 +255,53 @@ func generateTitleFromBranch(branchName string) string {
 	return title
 }
 
-func generatePRBody(issue *ghapi.Issue) string {
+func generatePRBody(issues []linkedIssue) string {
 	var sb strings.Builder
 
-	if issue != nil {
-		sb.WriteString("## Description\n\n")
-		if issue.Body != "" {
-			sb.WriteString(issue.Body)
-		} else {
-			sb.WriteString(fmt.Sprintf("Resolves #%d", issue.Number))
-		}
-		sb.WriteString("\n\n")
-		sb.WriteString(fmt.Sprintf("Closes #%d\n", issue.Number))
-	} else {
+	if len(issues) == 0 {
 		sb.WriteString("## Description\n\n")
 		sb.WriteString("<!-- Describe your changes here -->\n\n")
 		sb.WriteString("## Checklist\n\n")
 		sb.WriteString("- [ ] Tests added/updated\n")
 		sb.WriteString("- [ ] Documentation updated\n")
 		sb.WriteString("- [ ] Code follows project conventions\n")
+		return sb.String()
+	}
+
+	sb.WriteString("## Description\n\n")
+	described := false
+	for _, li := range issues {
+		if li.details == nil {
+			continue
+		}
+		if len(issues) > 1 {
+			sb.WriteString(fmt.Sprintf("### %s %s\n\n", li.key, li.details.Title))
+		}
+		if li.details.Body != "" {
+			sb.WriteString(li.details.Body)
+		} else {
+			sb.WriteString(fmt.Sprintf("Resolves %s", li.key))
+		}
+		sb.WriteString("\n\n")
+		described = true
+	}
+	if !described {
+		sb.WriteString("<!-- Describe your changes here -->\n\n")
+	}
+
+	for _, li := range issues {
+		switch {
+		case li.details != nil:
+			sb.WriteString(fmt.Sprintf("Closes %s\n", li.key))
+		case li.key.IsGitHub():
+			// Could not be fetched; don't close what may not exist
+		default:
+			if link := naming.Keys.Link(li.key); link != "" {
+				sb.WriteString(fmt.Sprintf("Resolves [%s](%s)\n", li.key, link))
+			} else {
+				sb.WriteString(fmt.Sprintf("Resolves %s\n", li.key))
+			}
+		}
 	}
 
 	return sb.String()
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+)
+
+func TestBranchIssues(t *testing.T) {
+	repo := git.Repo{Host: "github.com", Owner: "octo", Name: "demo"}
+	tests := []struct {
+		name       string
+		branch     string
+		recorded   string
+		wantKeys   []issue.Key
+		wantUnsure []issue.Key
+	}{
+		{
+			name:     "single issue",
+			branch:   "feature/GH-7-add-login",
+			wantKeys: []issue.Key{issue.GitHubKey(7)},
+		},
+		{
+			// Regression: the title "404 page shows blank" is not issue #404
+			name:       "title starting with digits",
+			branch:     "bugfix/GH-42-404-page-shows-blank",
+			wantKeys:   []issue.Key{issue.GitHubKey(42)},
+			wantUnsure: []issue.Key{issue.GitHubKey(404)},
+		},
+		{
+			name:     "issue of another repository",
+			branch:   "feature/acme+product-7-cross-thing",
+			wantKeys: []issue.Key{{Tracker: issue.GitHub, Project: "acme/product", ID: "7"}},
+		},
+		{
+			name:     "recorded issues",
+			branch:   "bugfix/GH-12-15-login-fails",
+			recorded: "#12,#15",
+			wantKeys: []issue.Key{issue.GitHubKey(12), issue.GitHubKey(15)},
+		},
+		{
+			name:     "recorded issue wins over the name",
+			branch:   "bugfix/GH-42-404-page-shows-blank",
+			recorded: "#42",
+			wantKeys: []issue.Key{issue.GitHubKey(42)},
+		},
+		{
+			name:   "no issue",
+			branch: "main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRepo(t)
+			if tt.recorded != "" {
+				runGit(t, "config", "branch."+tt.branch+"."+branchIssuesKey, tt.recorded)
+			}
+
+			keys, unsure := branchIssues(tt.branch, repo)
+			if !slices.Equal(keys, tt.wantKeys) || !slices.Equal(unsure, tt.wantUnsure) {
+				t.Errorf("branchIssues(%q) = %v, %v, want %v, %v", tt.branch, keys, unsure, tt.wantKeys, tt.wantUnsure)
+			}
+		})
+	}
+}
+
+func TestConfirmUnsureIssuesNonInteractive(t *testing.T) {
+	useDefaultConfig(t)
+
+	keys := []issue.Key{issue.GitHubKey(42)}
+	got := confirmUnsureIssues(keys, []issue.Key{issue.GitHubKey(404)})
+	if !slices.Equal(got, keys) {
+		t.Errorf("confirmUnsureIssues() = %v, want %v", got, keys)
+	}
+}
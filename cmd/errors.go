@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+	"github.com/jesusgpo/gh-buddy/internal/ui"
+)
+
+// errorHint returns advice on how to fix err, or "" when there is none.
+func errorHint(err error) string {
+	if errors.Is(err, ghapi.ErrPullRequest) {
+		return "use `gh pr view` to see the pull request, or pass an issue number with --issue"
+	}
+	var apiErr *ghapi.APIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+
+	switch {
+	case errors.Is(err, ghapi.ErrUnauthorized) && len(apiErr.Scopes) > 0:
+		return fmt.Sprintf("run `gh auth refresh -s %s`", strings.Join(apiErr.Scopes, ","))
+	case errors.Is(err, ghapi.ErrUnauthorized):
+		return "run `gh auth login`, or check that your token can access this repository"
+	case errors.Is(err, ghapi.ErrSSO) && apiErr.SSOURL != "":
+		return fmt.Sprintf("authorize your token for the organization's SAML SSO at %s", apiErr.SSOURL)
+	case errors.Is(err, ghapi.ErrSSO):
+		return "run `gh auth refresh` to authorize your token for the organization's SAML SSO"
+	case errors.Is(err, ghapi.ErrRateLimited) && !apiErr.Reset.IsZero():
+		wait := time.Until(apiErr.Reset).Round(time.Second)
+		return fmt.Sprintf("the GitHub API rate limit resets at %s (in %s)", apiErr.Reset.Format("15:04:05"), wait)
+	case errors.Is(err, ghapi.ErrRateLimited):
+		return "the GitHub API rate limit was hit; wait a few minutes and try again"
+	case errors.Is(err, ghapi.ErrValidation) && len(rejectedFields(apiErr)) > 0:
+		return fmt.Sprintf("GitHub rejected these fields: %s; check the values given", strings.Join(rejectedFields(apiErr), ", "))
+	case errors.Is(err, ghapi.ErrValidation):
+		return "GitHub rejected the request; check the base branch, title and labels given"
+	case errors.Is(err, ghapi.ErrNotFound):
+		return "check the issue number, and that your token can access the repository"
+	}
+	return ""
+}
+
+// rejectedFields returns the names of the fields a validation error
+// rejected. Errors not tied to a field, such as an existing pull request,
+// only have a message.
+func rejectedFields(err *ghapi.APIError) []string {
+	var fields []string
+	for _, f := range err.Fields {
+		if f.Field != "" {
+			fields = append(fields, f.Field)
+		}
+	}
+	return fields
+}
+
+// showHint prints advice on how to fix err, if there is any.
+func showHint(err error) {
+	if hint := errorHint(err); hint != "" {
+		ui.Info("Hint: %s", hint)
+	}
+}
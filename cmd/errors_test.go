@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+)
+
+func TestErrorHint(t *testing.T) {
+	wrap := func(e *ghapi.APIError) error { return fmt.Errorf("failed to fetch issue #42: %w", e) }
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "missing scopes",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrUnauthorized, Scopes: []string{"read:project", "repo"}}),
+			want: "run `gh auth refresh -s read:project,repo`",
+		},
+		{
+			name: "unauthorized",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrUnauthorized}),
+			want: "run `gh auth login`",
+		},
+		{
+			name: "SSO with URL",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrSSO, SSOURL: "https://github.com/orgs/acme/sso"}),
+			want: "at https://github.com/orgs/acme/sso",
+		},
+		{
+			name: "SSO",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrSSO}),
+			want: "run `gh auth refresh`",
+		},
+		{
+			name: "rate limited with reset",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrRateLimited, Reset: time.Now().Add(time.Minute)}),
+			want: "rate limit resets at",
+		},
+		{
+			name: "rate limited",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrRateLimited}),
+			want: "wait a few minutes",
+		},
+		{
+			name: "validation with fields",
+			err: wrap(&ghapi.APIError{Kind: ghapi.ErrValidation, Fields: []ghapi.FieldError{
+				{Resource: "PullRequest", Field: "base", Code: "invalid"},
+				{Resource: "PullRequest", Code: "custom", Message: "A pull request already exists"},
+			}}),
+			want: "GitHub rejected these fields: base;",
+		},
+		{
+			name: "validation",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrValidation}),
+			want: "check the base branch",
+		},
+		{
+			name: "not found",
+			err:  wrap(&ghapi.APIError{Kind: ghapi.ErrNotFound}),
+			want: "check the issue number",
+		},
+		{
+			name: "pull request",
+			err:  fmt.Errorf("issue #42 %w", ghapi.ErrPullRequest),
+			want: "`gh pr view`",
+		},
+		{
+			name: "other API error",
+			err:  wrap(&ghapi.APIError{StatusCode: 500, Message: "Server Error"}),
+		},
+		{
+			name: "not an API error",
+			err:  errors.New("exit status 1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorHint(tt.err)
+			if (tt.want == "" && got != "") || !strings.Contains(got, tt.want) {
+				t.Errorf("errorHint() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
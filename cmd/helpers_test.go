@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/config"
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+	"github.com/jesusgpo/gh-buddy/internal/git"
+)
+
+// setupRepo creates a repository with an empty commit on main, isolated from
+// the user's git and gh-buddy config, and makes it the working directory.
+// The commands run with the default config, without prompts. It returns the
+// repository path.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Buddy")
+	t.Setenv("GIT_AUTHOR_EMAIL", "buddy@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Buddy")
+	t.Setenv("GIT_COMMITTER_EMAIL", "buddy@example.com")
+	t.Chdir(dir)
+	useDefaultConfig(t)
+
+	runGit(t, "init", "--quiet", "--initial-branch=main")
+	runGit(t, "commit", "--quiet", "--allow-empty", "--message", "Initial commit")
+	return dir
+}
+
+// useDefaultConfig makes the commands use the default config, without
+// prompts, for the rest of the test.
+func useDefaultConfig(t *testing.T) {
+	t.Helper()
+	prevCfg, prevNaming, prevDefaults := cfg, naming, useDefaults
+	t.Cleanup(func() { cfg, naming, useDefaults = prevCfg, prevNaming, prevDefaults })
+
+	cfg = config.Default()
+	var err error
+	if naming, err = cfg.Convention(); err != nil {
+		t.Fatal(err)
+	}
+	useDefaults = true
+}
+
+// runGit runs git directly, not through the runner under test.
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// useGit makes git commands answer with output, without running git, for the
+// rest of the test. It returns the runner recording the commands.
+func useGit(t *testing.T, output func(args []string) (string, error)) *git.DryRunner {
+	t.Helper()
+	r := &git.DryRunner{Output: output}
+	git.SetRunner(r)
+	t.Cleanup(func() { git.SetRunner(git.ExecRunner{}) })
+	return r
+}
+
+// gitFailure is a failed git command with the given exit status.
+func gitFailure(args []string, code int) error {
+	return &git.Error{Args: args, ExitCode: code, Err: fmt.Errorf("exit status %d", code)}
+}
+
+// fakeClient answers GetRepo and GetIssue from maps keyed by "owner/repo" and
+// "owner/repo#N"; anything missing is not found. Other calls panic.
+type fakeClient struct {
+	ghapi.Client
+	repos  map[string]*ghapi.Repository
+	issues map[string]*ghapi.Issue
+}
+
+func (c *fakeClient) GetRepo(repo string) (*ghapi.Repository, error) {
+	if r, ok := c.repos[repo]; ok {
+		return r, nil
+	}
+	return nil, &ghapi.APIError{Kind: ghapi.ErrNotFound, StatusCode: 404, Message: "Not Found"}
+}
+
+func (c *fakeClient) GetIssue(repo string, number int) (*ghapi.Issue, error) {
+	if i, ok := c.issues[fmt.Sprintf("%s#%d", repo, number)]; ok {
+		return i, nil
+	}
+	return nil, &ghapi.APIError{Kind: ghapi.ErrNotFound, StatusCode: 404, Message: "Not Found"}
+}
+
+// useClient makes the commands talk to c for the rest of the test.
+func useClient(t *testing.T, c ghapi.Client) {
+	t.Helper()
+	prev := client
+	client = c
+	t.Cleanup(func() { client = prev })
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/jesusgpo/gh-buddy/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func newLintBranchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint-branch <name>",
+		Short: "Check a branch name against git rules and the branch policy",
+		Long: `Check a branch name against git's ref name rules (as in git check-ref-format)
+and the branch policy configured in .gh-buddy.yml.
+
+Exits with a non-zero status when the name is invalid.`,
+		Example: `  # Check a branch name
+  gh buddy lint-branch feature/GH-42-add-login`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLintBranch(args[0])
+		},
+	}
+
+	return cmd
+}
+
+func runLintBranch(name string) error {
+	if err := naming.Validate(name); err != nil {
+		return err
+	}
+	ui.Success("%q is a valid branch name", name)
+	return nil
+}
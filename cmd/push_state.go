@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/prompt"
+	"github.com/jesusgpo/gh-buddy/internal/ui"
+)
+
+// pushAction is what the remote branch needs before a pull request can be
+// opened from it.
+type pushAction int
+
+const (
+	// pushNone means the remote branch already has every local commit.
+	pushNone pushAction = iota
+	// pushNew means the branch is not on the remote yet.
+	pushNew
+	// pushFastForward means the local branch is ahead of the remote one.
+	pushFastForward
+	// pushForce means the branches have diverged and the remote one is
+	// replaced with --force-with-lease.
+	pushForce
+)
+
+// planPush compares the branch with the base and with its copy on the
+// remote, and decides how to push it. It refuses a branch with no commits
+// over the base, and asks before force-pushing a diverged branch.
+func planPush(rc repoContext, branchName, baseBranch string) (pushAction, error) {
+	if err := git.FetchLatest(rc.baseRemote); err != nil {
+		return pushNone, err
+	}
+	baseRef := fmt.Sprintf("%s/%s", rc.baseRemote, baseBranch)
+	commits, err := git.CountCommits(baseRef, branchName)
+	if err != nil {
+		return pushNone, err
+	}
+	if commits == 0 {
+		return pushNone, fmt.Errorf("branch %q has no commits over %s; commit your changes before creating a pull request", branchName, baseRef)
+	}
+
+	remote := pushRemote(branchName)
+	state, err := git.BranchPushState(remote, branchName)
+	if err != nil {
+		return pushNone, err
+	}
+	remoteRef := fmt.Sprintf("%s/%s", remote, branchName)
+	switch {
+	case !state.Pushed:
+		ui.Info("Branch %q is not on %s yet; it will be pushed", branchName, remote)
+		return pushNew, nil
+	case state.Diverged():
+		ui.Warning("Branch %q has diverged from %s: %d local and %d remote commit(s) differ", branchName, remoteRef, state.Ahead, state.Behind)
+		if useDefaults {
+			return pushNone, fmt.Errorf("branch %q has diverged from %s; run `git push --force-with-lease` or pull first", branchName, remoteRef)
+		}
+		if !prompt.Confirm(fmt.Sprintf("Force-push with --force-with-lease, dropping the %d remote commit(s)?", state.Behind), false) {
+			return pushNone, fmt.Errorf("aborted: branch %q has diverged from %s", branchName, remoteRef)
+		}
+		return pushForce, nil
+	case state.Ahead > 0:
+		ui.Info("Branch %q is %d commit(s) ahead of %s; it will be pushed", branchName, state.Ahead, remoteRef)
+		return pushFastForward, nil
+	case state.Behind > 0:
+		ui.Warning("%s has %d commit(s) that are not in your local branch; the pull request will include them", remoteRef, state.Behind)
+		return pushNone, nil
+	default:
+		ui.Info("Branch %q is up to date with %s", branchName, remoteRef)
+		return pushNone, nil
+	}
+}
+
+// pushRemote returns the remote the branch is pushed to: the remote it
+// tracks a branch of the same name on, as set by an earlier `git push -u`,
+// or the configured remote. A branch tracking the base it was created from
+// does not count, as that may be the upstream repository of a fork.
+func pushRemote(branchName string) string {
+	remote := git.BranchConfig(branchName, "remote")
+	if remote == "" || remote == "." || git.BranchConfig(branchName, "merge") != "refs/heads/"+branchName {
+		return cfg.Remote
+	}
+	return remote
+}
+
+// pushBranch pushes the branch as planned by planPush.
+func pushBranch(action pushAction, branchName string) error {
+	if action == pushNone {
+		return nil
+	}
+
+	remote := pushRemote(branchName)
+	spinner, _ := ui.StartSpinner(fmt.Sprintf("Pushing branch to %s...", remote))
+	push := git.PushBranch
+	if action == pushForce {
+		push = git.ForcePushBranch
+	}
+	if err := push(remote, branchName); err != nil {
+		spinner.Fail(fmt.Sprintf("Push failed: %v", err))
+		return err
+	}
+	spinner.Success(fmt.Sprintf("Branch pushed to %s", remote))
+	return nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/git"
+)
+
+// setupPushRepo creates a repository whose origin has main, with git
+// simulating the remotes, and switches to a new topic branch.
+func setupPushRepo(t *testing.T) {
+	t.Helper()
+	setupRepo(t)
+	git.SetOffline(true)
+	t.Cleanup(func() { git.SetOffline(false) })
+
+	runGit(t, "remote", "add", "origin", "https://github.com/octo/demo.git")
+	runGit(t, "update-ref", "refs/remotes/origin/main", "HEAD")
+	runGit(t, "switch", "--quiet", "--create", "topic")
+}
+
+// commit adds an empty commit to the current branch.
+func commit(t *testing.T, message string) {
+	t.Helper()
+	runGit(t, "commit", "--quiet", "--allow-empty", "--message", message)
+}
+
+func TestPlanPush(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T)
+		want  pushAction
+		// wantErr is part of the expected error, if any.
+		wantErr string
+	}{
+		{
+			name:    "no commits over the base",
+			setup:   func(t *testing.T) {},
+			wantErr: "no commits over origin/main",
+		},
+		{
+			name:  "not pushed",
+			setup: func(t *testing.T) { commit(t, "Fix") },
+			want:  pushNew,
+		},
+		{
+			name: "up to date",
+			setup: func(t *testing.T) {
+				commit(t, "Fix")
+				runGit(t, "update-ref", "refs/remotes/origin/topic", "HEAD")
+			},
+			want: pushNone,
+		},
+		{
+			name: "ahead",
+			setup: func(t *testing.T) {
+				commit(t, "Fix")
+				runGit(t, "update-ref", "refs/remotes/origin/topic", "HEAD")
+				commit(t, "Fix again")
+			},
+			want: pushFastForward,
+		},
+		{
+			name: "behind",
+			setup: func(t *testing.T) {
+				commit(t, "Fix")
+				commit(t, "Fix from another machine")
+				runGit(t, "update-ref", "refs/remotes/origin/topic", "HEAD")
+				runGit(t, "reset", "--quiet", "--hard", "HEAD~1")
+			},
+			want: pushNone,
+		},
+		{
+			name: "diverged with -y",
+			setup: func(t *testing.T) {
+				commit(t, "Fix")
+				runGit(t, "update-ref", "refs/remotes/origin/topic", "HEAD")
+				runGit(t, "commit", "--quiet", "--amend", "--allow-empty", "--message", "Fix, reworded")
+			},
+			wantErr: "has diverged from origin/topic",
+		},
+		{
+			name: "up to date on the tracked remote",
+			setup: func(t *testing.T) {
+				commit(t, "Fix")
+				runGit(t, "remote", "add", "fork", "https://github.com/me/demo.git")
+				runGit(t, "update-ref", "refs/remotes/fork/topic", "HEAD")
+				runGit(t, "config", "branch.topic.remote", "fork")
+				runGit(t, "config", "branch.topic.merge", "refs/heads/topic")
+			},
+			want: pushNone,
+		},
+		{
+			name: "tracking the base",
+			setup: func(t *testing.T) {
+				commit(t, "Fix")
+				runGit(t, "remote", "add", "upstream", "https://github.com/acme/demo.git")
+				runGit(t, "update-ref", "refs/remotes/upstream/topic", "HEAD")
+				runGit(t, "config", "branch.topic.remote", "upstream")
+				runGit(t, "config", "branch.topic.merge", "refs/heads/main")
+			},
+			want: pushNew,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupPushRepo(t)
+			tt.setup(t)
+
+			got, err := planPush(repoContext{baseRemote: "origin"}, "topic", "main")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("planPush() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("planPush() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("planPush() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushBranchToTrackedRemote(t *testing.T) {
+	setupPushRepo(t)
+	commit(t, "Fix")
+	runGit(t, "remote", "add", "fork", "https://github.com/me/demo.git")
+	runGit(t, "update-ref", "refs/remotes/fork/topic", "HEAD")
+	runGit(t, "config", "branch.topic.remote", "fork")
+	runGit(t, "config", "branch.topic.merge", "refs/heads/topic")
+	commit(t, "Fix again")
+
+	if err := pushBranch(pushFastForward, "topic"); err != nil {
+		t.Fatalf("pushBranch() error = %v", err)
+	}
+	head := runGit(t, "rev-parse", "HEAD")
+	if got := runGit(t, "rev-parse", "refs/remotes/fork/topic"); got != head {
+		t.Errorf("fork/topic = %s, want the pushed %s", got, head)
+	}
+	if exists, err := git.RemoteBranchExists("origin", "topic"); err != nil || exists {
+		t.Errorf("RemoteBranchExists(origin) = %v, %v, want the branch not pushed to origin", exists, err)
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+	"github.com/jesusgpo/gh-buddy/internal/git"
+)
+
+const replayBranch = "bugfix/GH-42-login-fails-with-expired-token"
+
+// setupReplay creates a repository whose origin is github.com/octo/demo,
+// with main already on it, and makes it the working directory. API
+// responses are served from testdata/<fixture> and git never reaches the
+// remote, so no network is needed.
+func setupReplay(t *testing.T, fixture string) {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupRepo(t)
+	t.Setenv(ghapi.ReplayEnv, path)
+	t.Cleanup(func() { git.SetOffline(false) })
+
+	runGit(t, "remote", "add", "origin", "https://github.com/octo/demo.git")
+	runGit(t, "update-ref", "refs/remotes/origin/main", "HEAD")
+	runGit(t, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/main")
+}
+
+// runBuddy runs the root command with the given arguments.
+func runBuddy(t *testing.T, args ...string) {
+	t.Helper()
+	cmd := NewRootCmd()
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("buddy %s: %v", strings.Join(args, " "), err)
+	}
+}
+
+func TestCreateBranchReplay(t *testing.T) {
+	setupReplay(t, "create_branch.json")
+
+	runBuddy(t, "create-branch", "--issue", "42", "-y")
+
+	if got := runGit(t, "branch", "--show-current"); got != replayBranch {
+		t.Errorf("current branch = %q, want %q", got, replayBranch)
+	}
+	// The branch was created on GitHub by linking it to the issue
+	if got := runGit(t, "config", "branch."+replayBranch+".merge"); got != "refs/heads/"+replayBranch {
+		t.Errorf("upstream = %q, want refs/heads/%s", got, replayBranch)
+	}
+	if got := runGit(t, "config", "branch."+replayBranch+"."+branchIssuesKey); got != "#42" {
+		t.Errorf("recorded issues = %q, want #42", got)
+	}
+}
+
+func TestCreatePRReplay(t *testing.T) {
+	setupReplay(t, "create_pr.json")
+	runGit(t, "switch", "--quiet", "--create", replayBranch)
+	runGit(t, "commit", "--quiet", "--allow-empty", "--message", "Refresh expired tokens")
+
+	// The fixture only answers a pull request whose body closes #42
+	runBuddy(t, "create-pr", "-y")
+
+	head := runGit(t, "rev-parse", "HEAD")
+	if got := runGit(t, "rev-parse", "refs/remotes/origin/"+replayBranch); got != head {
+		t.Errorf("origin/%s = %s, want the pushed %s", replayBranch, got, head)
+	}
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jesusgpo/gh-buddy/internal/config"
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/prompt"
+	"github.com/jesusgpo/gh-buddy/internal/ui"
+)
+
+// repoContext describes where a command works. Branches are pushed to the
+// repository of the configured remote; issues and pull requests live in its
+// upstream, which differs from it only for forks.
+type repoContext struct {
+	origin   git.Repo
+	upstream git.Repo
+	// baseRemote is the remote base branches are fetched from: the upstream
+	// remote of a fork, or the configured remote.
+	baseRemote string
+}
+
+// isFork reports whether branches are pushed to a fork of the upstream.
+func (r repoContext) isFork() bool {
+	return r.origin != r.upstream
+}
+
+// head returns the pull request head for a branch, "owner:branch" for forks.
+func (r repoContext) head(branch string) string {
+	if r.isFork() {
+		return r.origin.Owner + ":" + branch
+	}
+	return branch
+}
+
+// newClient returns the API client for a host; tests replace it.
+var newClient = ghapi.New
+
+// currentRepo resolves the GitHub repository of the configured remote, with
+// the host overridden by the hostname setting, sets up the API client for
+// that host and detects whether the repository is a fork. A fork's parent is
+// taken from the upstream remote, or else from GitHub.
+func currentRepo() (repoContext, error) {
+	origin, err := remoteRepo(cfg.Remote)
+	if err != nil && cfg.Remote == config.DefaultRemote && git.IsRepository() {
+		origin, err = detectRemote()
+		if err != nil {
+			return repoContext{}, err
+		}
+	}
+	if err != nil {
+		return repoContext{}, fmt.Errorf("not in a git repository or no %s remote: %w", cfg.Remote, err)
+	}
+	client, err = newClient(origin.Host, issueCache())
+	if err != nil {
+		return repoContext{}, err
+	}
+
+	rc := repoContext{origin: origin, upstream: origin, baseRemote: cfg.Remote}
+	if cfg.UpstreamRemote != "" && cfg.UpstreamRemote != cfg.Remote {
+		if upstream, err := remoteRepo(cfg.UpstreamRemote); err == nil && upstream != origin {
+			rc.upstream = upstream
+			rc.baseRemote = cfg.UpstreamRemote
+			ui.Info("Working on a fork: issues and PRs target %s", upstream.Slug())
+			return rc, nil
+		}
+	}
+
+	info, err := client.GetRepo(origin.Slug())
+	if err == nil && info.Fork && info.Parent != nil {
+		owner, name, _ := strings.Cut(info.Parent.FullName, "/")
+		rc.upstream = git.Repo{Host: origin.Host, Owner: owner, Name: name}
+		ui.Info("Working on a fork: issues and PRs target %s", rc.upstream.Slug())
+		ui.Warning("No %q remote; base branches come from %s. Add one with: git remote add %s <url of %s>",
+			cfg.UpstreamRemote, cfg.Remote, cfg.UpstreamRemote, rc.upstream.Slug())
+	}
+	return rc, nil
+}
+
+// detectRemote looks for the remote to use when there is no "origin": the
+// one whose URL points at a repository, on github.com or any other host,
+// leaving out the upstream remote unless it is the only one. When several
+// match the user picks one. cfg.Remote is updated to the chosen remote.
+func detectRemote() (git.Repo, error) {
+	names, err := git.Remotes()
+	if err != nil {
+		return git.Repo{}, err
+	}
+	var remotes []string
+	repos := map[string]git.Repo{}
+	for _, name := range names {
+		repo, err := remoteRepo(name)
+		if err != nil {
+			continue
+		}
+		repos[name] = repo
+		if name != cfg.UpstreamRemote {
+			remotes = append(remotes, name)
+		}
+	}
+	if len(remotes) == 0 {
+		if _, ok := repos[cfg.UpstreamRemote]; !ok {
+			return git.Repo{}, fmt.Errorf("no %q remote and no other remote points at a GitHub repository; choose one with --remote", cfg.Remote)
+		}
+		remotes = []string{cfg.UpstreamRemote}
+	}
+
+	chosen := remotes[0]
+	if len(remotes) > 1 {
+		if useDefaults {
+			return git.Repo{}, fmt.Errorf("several remotes point at GitHub repositories (%s); choose one with --remote", strings.Join(remotes, ", "))
+		}
+		options := make([]string, len(remotes))
+		for i, name := range remotes {
+			options[i] = fmt.Sprintf("%s (%s)", name, repos[name].Slug())
+		}
+		idx, err := prompt.Select(fmt.Sprintf("No %q remote. Which remote should be used?", cfg.Remote), options)
+		if err != nil {
+			return git.Repo{}, err
+		}
+		chosen = remotes[idx]
+	} else {
+		ui.Info("No %q remote; using %s (%s)", cfg.Remote, chosen, repos[chosen].Slug())
+	}
+	cfg.Remote = chosen
+	return repos[chosen], nil
+}
+
+// remoteRepo returns the repository of a git remote, with the host
+// overridden by the hostname setting.
+func remoteRepo(remote string) (git.Repo, error) {
+	repo, err := git.RemoteRepo(remote)
+	if err != nil {
+		return git.Repo{}, err
+	}
+	if cfg.Hostname != "" {
+		repo.Host = cfg.Hostname
+	}
+	return repo, nil
+}
+
+// issueCache returns the configured issue cache, or nil when it is disabled
+// or unavailable.
+func issueCache() *ghapi.Cache {
+	if cfg.Cache.Disabled {
+		return nil
+	}
+	cache, err := ghapi.NewCache(cfg.Cache.TTL)
+	if err != nil {
+		return nil
+	}
+	cache.Refresh = refresh
+	cache.Warn = ui.Warning
+	return cache
+}
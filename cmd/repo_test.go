@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+	"github.com/jesusgpo/gh-buddy/internal/git"
+)
+
+// useFakeClient makes currentRepo set up c as the API client for the rest
+// of the test.
+func useFakeClient(t *testing.T, c ghapi.Client) {
+	t.Helper()
+	prevNew, prevClient := newClient, client
+	newClient = func(string, *ghapi.Cache) (ghapi.Client, error) { return c, nil }
+	t.Cleanup(func() { newClient, client = prevNew, prevClient })
+}
+
+func TestCurrentRepoFork(t *testing.T) {
+	me := git.Repo{Host: "github.com", Owner: "me", Name: "demo"}
+	octo := git.Repo{Host: "github.com", Owner: "octo", Name: "demo"}
+	fork := &ghapi.Repository{FullName: "me/demo", Fork: true, Parent: &ghapi.Repository{FullName: "octo/demo"}}
+
+	tests := []struct {
+		name         string
+		upstreamURL  string
+		repos        map[string]*ghapi.Repository
+		wantUpstream git.Repo
+		wantBase     string
+		wantHead     string
+	}{
+		{
+			name:         "upstream remote",
+			upstreamURL:  "https://github.com/octo/demo.git",
+			wantUpstream: octo,
+			wantBase:     "upstream",
+			wantHead:     "me:feature/x",
+		},
+		{
+			name:         "fork reported by GitHub",
+			repos:        map[string]*ghapi.Repository{"me/demo": fork},
+			wantUpstream: octo,
+			wantBase:     "origin",
+			wantHead:     "me:feature/x",
+		},
+		{
+			name:         "upstream remote pointing at origin",
+			upstreamURL:  "git@github.com:me/demo.git",
+			repos:        map[string]*ghapi.Repository{"me/demo": {FullName: "me/demo"}},
+			wantUpstream: me,
+			wantBase:     "origin",
+			wantHead:     "feature/x",
+		},
+		{
+			name:         "not a fork",
+			repos:        map[string]*ghapi.Repository{"me/demo": {FullName: "me/demo"}},
+			wantUpstream: me,
+			wantBase:     "origin",
+			wantHead:     "feature/x",
+		},
+		{
+			name:         "repository not readable",
+			wantUpstream: me,
+			wantBase:     "origin",
+			wantHead:     "feature/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRepo(t)
+			runGit(t, "remote", "add", "origin", "https://github.com/me/demo.git")
+			if tt.upstreamURL != "" {
+				runGit(t, "remote", "add", "upstream", tt.upstreamURL)
+			}
+			useFakeClient(t, &fakeClient{repos: tt.repos})
+
+			rc, err := currentRepo()
+			if err != nil {
+				t.Fatalf("currentRepo() error = %v", err)
+			}
+			if rc.origin != me || rc.upstream != tt.wantUpstream || rc.baseRemote != tt.wantBase {
+				t.Errorf("currentRepo() = %+v, want origin %v, upstream %v from %s", rc, me, tt.wantUpstream, tt.wantBase)
+			}
+			if got := rc.head("feature/x"); got != tt.wantHead {
+				t.Errorf("head() = %q, want %q", got, tt.wantHead)
+			}
+		})
+	}
+}
+
+func TestDetectRemote(t *testing.T) {
+	tests := []struct {
+		name    string
+		remotes map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "single GitHub remote",
+			remotes: map[string]string{"github": "git@github.com:octo/demo.git", "backup": "/srv/git/demo.git"},
+			want:    "github",
+		},
+		{
+			name:    "GitHub Enterprise Server remote",
+			remotes: map[string]string{"work": "https://ghe.example.com/octo/demo.git"},
+			want:    "work",
+		},
+		{
+			name:    "upstream left out",
+			remotes: map[string]string{"mine": "https://github.com/me/demo.git", "upstream": "https://github.com/octo/demo.git"},
+			want:    "mine",
+		},
+		{
+			name:    "only upstream",
+			remotes: map[string]string{"upstream": "https://github.com/octo/demo.git"},
+			want:    "upstream",
+		},
+		{
+			name:    "no repository remote",
+			remotes: map[string]string{"backup": "/srv/git/demo.git"},
+			wantErr: true,
+		},
+		{
+			name:    "several without prompts",
+			remotes: map[string]string{"a": "https://github.com/me/demo.git", "b": "https://github.com/octo/demo.git"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDefaultConfig(t)
+			useGit(t, func(args []string) (string, error) {
+				switch {
+				case slices.Equal(args, []string{"remote"}):
+					names := slices.Sorted(maps.Keys(tt.remotes))
+					return strings.Join(names, "\n") + "\n", nil
+				case len(args) == 3 && args[1] == "get-url" && tt.remotes[args[2]] != "":
+					return tt.remotes[args[2]] + "\n", nil
+				}
+				return "", gitFailure(args, 2)
+			})
+
+			repo, err := detectRemote()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectRemote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cfg.Remote != tt.want {
+				t.Errorf("detectRemote() chose %q, want %q", cfg.Remote, tt.want)
+			}
+			if want, _ := remoteRepo(tt.want); repo != want {
+				t.Errorf("detectRemote() = %v, want %v", repo, want)
+			}
+		})
+	}
+}
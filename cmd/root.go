@@ -3,6 +3,10 @@ package cmd
 import (
 	"os"
 
+	"github.com/jesusgpo/gh-buddy/internal/branch"
+	"github.com/jesusgpo/gh-buddy/internal/config"
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+	"github.com/jesusgpo/gh-buddy/internal/git"
 	"github.com/jesusgpo/gh-buddy/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -10,6 +14,18 @@ import (
 var (
 	version     = "dev"
 	useDefaults bool
+	hostname    string
+	remote      string
+	refresh     bool
+	trace       bool
+
+	// cfg is the effective configuration, loaded before any command runs.
+	cfg *config.Config
+	// naming is the branch naming convention derived from cfg.
+	naming branch.Convention
+	// client talks to the GitHub API of the current repository's host; it is
+	// set by currentRepo.
+	client ghapi.Client
 )
 
 func NewRootCmd() *cobra.Command {
@@ -23,12 +39,43 @@ consistent naming conventions, directly from GitHub issues.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		Version:       version,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Replayed runs must not reach the remote either
+			git.SetOffline(os.Getenv(ghapi.ReplayEnv) != "")
+			var runner git.Runner = git.ExecRunner{}
+			if trace {
+				runner = git.TraceRunner{Runner: runner, Out: os.Stderr}
+			}
+			git.SetRunner(runner)
+			var err error
+			cfg, err = config.Load()
+			if err != nil {
+				return err
+			}
+			naming, err = cfg.Convention()
+			if err != nil {
+				return err
+			}
+			if hostname != "" {
+				cfg.Hostname = hostname
+			}
+			if remote != "" {
+				cfg.Remote = remote
+			}
+			return nil
+		},
 	}
 
 	rootCmd.PersistentFlags().BoolVarP(&useDefaults, "yes", "y", false, "use the default proposed fields")
+	rootCmd.PersistentFlags().StringVar(&hostname, "hostname", "", "GitHub host to use instead of the one in the remote URL")
+	rootCmd.PersistentFlags().StringVar(&remote, "remote", "", "git remote to fetch bases from and push branches to (default: origin)")
+	rootCmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "revalidate cached issues with GitHub")
+	rootCmd.PersistentFlags().BoolVar(&trace, "trace", false, "print each git command and how long it took")
 
 	rootCmd.AddCommand(newCreateBranchCmd())
 	rootCmd.AddCommand(newCreatePRCmd())
+	rootCmd.AddCommand(newLintBranchCmd())
+	rootCmd.AddCommand(newWorktreesCmd())
 
 	return rootCmd
 }
@@ -37,6 +84,7 @@ func Execute() {
 	rootCmd := NewRootCmd()
 	if err := rootCmd.Execute(); err != nil {
 		ui.Error("%v", err)
+		showHint(err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jesusgpo/gh-buddy/internal/config"
+	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/prompt"
+	"github.com/jesusgpo/gh-buddy/internal/ui"
+)
+
+// uncommittedPolicies are the ways of dealing with uncommitted changes, in
+// the order they are offered.
+var uncommittedPolicies = []struct {
+	name, label string
+}{
+	{config.OnUncommittedReapply, "Stash them and re-apply them on the new branch"},
+	{config.OnUncommittedCarry, "Carry them over to the new branch"},
+	{config.OnUncommittedStash, "Stash them and leave them stashed"},
+	{config.OnUncommittedAbort, "Abort"},
+}
+
+// switchWithChanges runs switchBranch after dealing with uncommitted changes
+// as branch.on_uncommitted says, or as the user chooses in interactive mode.
+// Stashed changes that should follow are re-applied afterwards, even when
+// switching failed, and conflicts are reported.
+func switchWithChanges(branchName string, switchBranch func() error) error {
+	dirty, err := git.HasUncommittedChanges()
+	if err != nil {
+		return err
+	}
+	if !dirty {
+		return switchBranch()
+	}
+
+	policy := cfg.Branch.OnUncommitted
+	ui.Warning("You have uncommitted changes")
+	if !useDefaults {
+		options := make([]string, len(uncommittedPolicies))
+		defaultIdx := 0
+		for i, p := range uncommittedPolicies {
+			options[i] = p.label
+			if p.name == policy {
+				defaultIdx = i
+			}
+		}
+		idx, err := prompt.SelectWithDefault("What do you want to do with them?", options, defaultIdx)
+		if err != nil {
+			return err
+		}
+		policy = uncommittedPolicies[idx].name
+	}
+
+	switch policy {
+	case config.OnUncommittedCarry:
+		ui.Info("Carrying uncommitted changes over to %q", branchName)
+		return switchBranch()
+	case config.OnUncommittedReapply, config.OnUncommittedStash:
+		message := fmt.Sprintf("gh-buddy: changes set aside before creating %s", branchName)
+		if err := git.Stash(message); err != nil {
+			return err
+		}
+		if policy == config.OnUncommittedStash {
+			ui.Info("Changes stashed as %q; bring them back with `git stash pop`", message)
+			return switchBranch()
+		}
+		err := switchBranch()
+		reapplyChanges()
+		return err
+	default:
+		return fmt.Errorf("you have uncommitted changes; commit or stash them first (set branch.on_uncommitted to change this)")
+	}
+}
+
+// reapplyChanges pops the stash made by switchWithChanges and reports
+// conflicts.
+func reapplyChanges() {
+	err := git.StashPop()
+	if err == nil {
+		ui.Success("Uncommitted changes re-applied")
+		return
+	}
+	files, _ := git.ConflictedFiles()
+	if len(files) == 0 {
+		ui.Warning("Could not re-apply your uncommitted changes; they are kept in the stash: %v", err)
+		return
+	}
+	ui.Warning("Re-applying your uncommitted changes caused conflicts in:")
+	for _, f := range files {
+		ui.Warning("  %s", f)
+	}
+	ui.Warning("Resolve them, then drop the stash with `git stash drop`")
+}
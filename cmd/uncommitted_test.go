@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/config"
+)
+
+func TestSwitchWithChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		clean  bool
+		// conflict makes the new branch change the file that was edited.
+		conflict    bool
+		wantErr     bool
+		wantSwitch  bool
+		wantChanges bool
+		wantStashes int
+	}{
+		{name: "clean tree", policy: config.OnUncommittedAbort, clean: true, wantSwitch: true},
+		{name: "carry", policy: config.OnUncommittedCarry, wantSwitch: true, wantChanges: true},
+		{name: "reapply", policy: config.OnUncommittedReapply, wantSwitch: true, wantChanges: true},
+		{name: "reapply with conflict", policy: config.OnUncommittedReapply, conflict: true, wantSwitch: true, wantChanges: true, wantStashes: 1},
+		{name: "stash", policy: config.OnUncommittedStash, wantSwitch: true, wantStashes: 1},
+		{name: "abort", policy: config.OnUncommittedAbort, wantErr: true, wantChanges: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRepo(t)
+			cfg.Branch.OnUncommitted = tt.policy
+			if err := os.WriteFile("notes.txt", []byte("one\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			runGit(t, "add", "notes.txt")
+			runGit(t, "commit", "--quiet", "--message", "Add notes")
+			if !tt.clean {
+				if err := os.WriteFile("notes.txt", []byte("two\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			switched := false
+			err := switchWithChanges("feature", func() error {
+				switched = true
+				runGit(t, "switch", "--quiet", "--create", "feature")
+				if tt.conflict {
+					if err := os.WriteFile("notes.txt", []byte("three\n"), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					runGit(t, "commit", "--quiet", "--all", "--message", "Change notes")
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("switchWithChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if switched != tt.wantSwitch {
+				t.Errorf("switched = %v, want %v", switched, tt.wantSwitch)
+			}
+			if changes := runGit(t, "status", "--porcelain") != ""; changes != tt.wantChanges {
+				t.Errorf("uncommitted changes = %v, want %v", changes, tt.wantChanges)
+			}
+			stashes := 0
+			if out := runGit(t, "stash", "list"); out != "" {
+				stashes = len(strings.Split(out, "\n"))
+			}
+			if stashes != tt.wantStashes {
+				t.Errorf("stashes = %d, want %d", stashes, tt.wantStashes)
+			}
+		})
+	}
+}
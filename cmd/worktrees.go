@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+	"github.com/jesusgpo/gh-buddy/internal/prompt"
+	"github.com/jesusgpo/gh-buddy/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// worktreeFromPattern is the value of --worktree when no path is given; the
+// path then comes from the worktree.path setting. It is not a usable path:
+// "<" and ">" are not allowed in Windows paths and must be quoted in shells,
+// so a directory given by the user is never mistaken for it.
+const worktreeFromPattern = "<worktree.path>"
+
+func newWorktreesCmd() *cobra.Command {
+	var prune bool
+
+	cmd := &cobra.Command{
+		Use:   "worktrees",
+		Short: "List worktrees with their issues and prune finished ones",
+		Long: `List the worktrees of the repository with the branch checked out in each
+and the state of its issues.
+
+With --prune, worktrees whose GitHub issues are all closed are removed. The
+main worktree, the current one and worktrees with uncommitted changes are
+kept. Branches are not deleted.`,
+		Example: `  # List worktrees
+  gh buddy worktrees
+
+  # Remove worktrees whose issues are closed
+  gh buddy worktrees --prune`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorktrees(prune)
+		},
+	}
+
+	cmd.Flags().BoolVar(&prune, "prune", false, "remove worktrees whose issues are all closed")
+
+	return cmd
+}
+
+func runWorktrees(prune bool) error {
+	rc, err := currentRepo()
+	if err != nil {
+		return err
+	}
+	repo := rc.upstream.Slug()
+
+	trees, err := git.Worktrees()
+	if err != nil {
+		return err
+	}
+	current, _ := git.TopLevel()
+
+	var rows [][]string
+	var finished []git.Worktree
+	for i, t := range trees {
+		if t.Bare {
+			continue
+		}
+		branchName := t.Branch
+		if branchName == "" {
+			branchName = "(detached)"
+		}
+		var states []string
+		closed := false
+		if t.Branch != "" {
+			keys, _ := branchIssues(t.Branch, rc.upstream)
+			states, closed = issueStates(keys, repo)
+		}
+		rows = append(rows, []string{t.Path, branchName, strings.Join(states, ", ")})
+		if closed && i > 0 && t.Path != current {
+			finished = append(finished, t)
+		}
+	}
+	ui.Table([]string{"Path", "Branch", "Issues"}, rows)
+
+	if !prune {
+		return nil
+	}
+	if len(finished) == 0 {
+		ui.Info("No worktrees with closed issues to prune")
+		return nil
+	}
+	for _, t := range finished {
+		ui.Info("Issues of %s are closed: %s", t.Branch, t.Path)
+	}
+	if !useDefaults && !prompt.Confirm(fmt.Sprintf("Remove %d worktree(s)?", len(finished)), true) {
+		ui.Warning("Cancelled.")
+		return nil
+	}
+	for _, t := range finished {
+		if err := git.RemoveWorktree(t.Path); err != nil {
+			ui.Warning("%v", err)
+			continue
+		}
+		ui.Success("Removed worktree %s", t.Path)
+	}
+	return nil
+}
+
+// issueStates describes the state of each issue, e.g. "#42 closed", and
+// reports whether there are GitHub issues and all of them are closed. Issues
+// from external trackers have no known state and keep a worktree alive.
+func issueStates(keys []issue.Key, repo string) ([]string, bool) {
+	states := make([]string, len(keys))
+	closed := len(keys) > 0
+	for i, key := range keys {
+		if !key.IsGitHub() {
+			states[i] = key.String()
+			closed = false
+			continue
+		}
+		details, err := client.GetIssue(issueRepo(key, repo), key.Number())
+		if err != nil {
+			states[i] = fmt.Sprintf("%s unknown", key)
+			closed = false
+			continue
+		}
+		states[i] = fmt.Sprintf("%s %s", key, details.State)
+		if details.State != "closed" {
+			closed = false
+		}
+	}
+	return states, closed
+}
+
+// createWorktree creates a worktree for branchName with add and prints
+// where it is. path is where the user asked for it, or worktreeFromPattern.
+func createWorktree(path string, keys []issue.Key, branchName string, add func(dir string) error) error {
+	dir, err := worktreeDir(path, keys, branchName)
+	if err != nil {
+		return err
+	}
+	if err := add(dir); err != nil {
+		return err
+	}
+	ui.Success("Worktree for %q created at %s", branchName, dir)
+	ui.Info("Start working on it with: cd %s", dir)
+	return nil
+}
+
+// worktreeDir returns the absolute path of a new worktree. A path from the
+// worktree.path pattern is relative to the main work tree; one given by the
+// user is relative to the current directory.
+func worktreeDir(path string, keys []issue.Key, branchName string) (string, error) {
+	if path != worktreeFromPattern {
+		return filepath.Abs(path)
+	}
+
+	trees, err := git.Worktrees()
+	if err != nil {
+		return "", err
+	}
+	main := trees[0].Path
+	path = strings.NewReplacer(
+		"{repo}", filepath.Base(main),
+		"{key}", naming.Key(keys),
+		"{branch}", strings.ReplaceAll(branchName, "/", "-"),
+	).Replace(cfg.Worktree.Path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(main, path)
+	}
+	return filepath.Clean(path), nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/ghapi"
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+)
+
+func TestWorktreeDir(t *testing.T) {
+	useDefaultConfig(t)
+	useGit(t, func(args []string) (string, error) {
+		if slices.Equal(args, []string{"worktree", "list", "--porcelain"}) {
+			return "worktree /src/demo\nHEAD 1234\nbranch refs/heads/main\n\nworktree /src/demo-GH-7\nHEAD 5678\nbranch refs/heads/feature/GH-7-x\n", nil
+		}
+		return "", gitFailure(args, 1)
+	})
+	abs := func(path string) string {
+		p, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		pattern string
+		keys    []issue.Key
+		branch  string
+		want    string
+	}{
+		{
+			name:    "default pattern",
+			path:    worktreeFromPattern,
+			pattern: "../{repo}-{key}",
+			keys:    []issue.Key{issue.GitHubKey(42)},
+			want:    filepath.FromSlash("/src/demo-GH-42"),
+		},
+		{
+			name:    "several issues",
+			path:    worktreeFromPattern,
+			pattern: "../{repo}-{key}",
+			keys:    []issue.Key{issue.GitHubKey(12), issue.GitHubKey(15)},
+			want:    filepath.FromSlash("/src/demo-GH-12-15"),
+		},
+		{
+			name:    "absolute pattern with the branch",
+			path:    worktreeFromPattern,
+			pattern: "/work/{branch}",
+			keys:    []issue.Key{issue.GitHubKey(42)},
+			branch:  "bugfix/GH-42-login-fails",
+			want:    filepath.FromSlash("/work/bugfix-GH-42-login-fails"),
+		},
+		{
+			name: "path given by the user",
+			path: "../review-42",
+			want: abs("../review-42"),
+		},
+		{
+			name: "directory named like the old sentinel",
+			path: "auto",
+			want: abs("auto"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Worktree.Path = tt.pattern
+			got, err := worktreeDir(tt.path, tt.keys, tt.branch)
+			if err != nil {
+				t.Fatalf("worktreeDir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("worktreeDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIssueStates(t *testing.T) {
+	useDefaultConfig(t)
+	useClient(t, &fakeClient{issues: map[string]*ghapi.Issue{
+		"octo/demo#1":    {Number: 1, State: "open"},
+		"octo/demo#2":    {Number: 2, State: "closed"},
+		"octo/demo#3":    {Number: 3, State: "closed"},
+		"acme/product#4": {Number: 4, State: "closed"},
+	}})
+	external := issue.Key{Tracker: "jira", Project: "PAY", ID: "881"}
+	crossRepo := issue.Key{Tracker: issue.GitHub, Project: "acme/product", ID: "4"}
+
+	tests := []struct {
+		name       string
+		keys       []issue.Key
+		wantStates []string
+		wantClosed bool
+	}{
+		{
+			name:       "all closed",
+			keys:       []issue.Key{issue.GitHubKey(2), issue.GitHubKey(3)},
+			wantStates: []string{"#2 closed", "#3 closed"},
+			wantClosed: true,
+		},
+		{
+			name:       "one still open",
+			keys:       []issue.Key{issue.GitHubKey(1), issue.GitHubKey(2)},
+			wantStates: []string{"#1 open", "#2 closed"},
+		},
+		{
+			name:       "other repository",
+			keys:       []issue.Key{crossRepo},
+			wantStates: []string{"acme/product#4 closed"},
+			wantClosed: true,
+		},
+		{
+			name:       "unknown state",
+			keys:       []issue.Key{issue.GitHubKey(2), issue.GitHubKey(99)},
+			wantStates: []string{"#2 closed", "#99 unknown"},
+		},
+		{
+			name:       "external tracker",
+			keys:       []issue.Key{issue.GitHubKey(2), external},
+			wantStates: []string{"#2 closed", "PAY-881"},
+		},
+		{
+			name:       "no issues",
+			wantStates: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states, closed := issueStates(tt.keys, "octo/demo")
+			if !slices.Equal(states, tt.wantStates) || closed != tt.wantClosed {
+				t.Errorf("issueStates() = %q, %v, want %q, %v", states, closed, tt.wantStates, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestCreateBranchWorktreePathNeedsEquals(t *testing.T) {
+	cmd := NewRootCmd()
+	cmd.SetArgs([]string{"create-branch", "--issue", "42", "--worktree", "../review-42"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--worktree=../review-42") {
+		t.Errorf("Execute() error = %v, want a hint to use --worktree=../review-42", err)
+	}
+}
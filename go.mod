@@ -2,7 +2,12 @@ module github.com/jesusgpo/gh-buddy
 
 go 1.24.0
 
-require github.com/spf13/cobra v1.10.2
+require (
+	github.com/pterm/pterm v0.12.83
+	github.com/spf13/cobra v1.10.2
+	golang.org/x/text v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	atomicgo.dev/cursor v0.2.0 // indirect
@@ -14,10 +19,8 @@ require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/lithammer/fuzzysearch v1.1.8 // indirect
 	github.com/mattn/go-runewidth v0.0.20 // indirect
-	github.com/pterm/pterm v0.12.83 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	golang.org/x/sys v0.41.0 // indirect
 	golang.org/x/term v0.40.0 // indirect
-	golang.org/x/text v0.34.0 // indirect
 )
@@ -1,72 +1,174 @@
 package branch
 
 import (
-	"fmt"
-	"regexp"
 	"strings"
-)
 
-// IssueType represents the type of issue for branch naming.
-type IssueType string
-
-const (
-	Feature  IssueType = "feature"
-	Bugfix   IssueType = "bugfix"
-	Hotfix   IssueType = "hotfix"
-	Release  IssueType = "release"
-	Chore    IssueType = "chore"
-	Docs     IssueType = "docs"
-	Refactor IssueType = "refactor"
-	Test     IssueType = "test"
-	Internal IssueType = "internal"
+	"github.com/jesusgpo/gh-buddy/internal/issue"
 )
 
-// AllIssueTypes returns all valid issue types.
-func AllIssueTypes() []IssueType {
-	return []IssueType{Feature, Bugfix, Hotfix, Release, Chore, Docs, Refactor, Test, Internal}
+// Convention holds the rules used to name branches.
+type Convention struct {
+	// Format is the branch name template.
+	Format *Template
+	// LegacyFormats are older templates still recognised by Parse.
+	LegacyFormats []*Template
+	// Prefix is the key prefix placed before GitHub issue numbers (e.g. "GH").
+	// Issues from external trackers use their project key instead.
+	Prefix string
+	// Keys describes the issue tracker whose keys appear in branch names.
+	Keys issue.Scheme
+	// SlugMaxLength caps the length of the slugified title.
+	SlugMaxLength int
+	// MaxLength caps the length of the whole branch name; 0 means no limit.
+	// The slug is shortened first to make room.
+	MaxLength int
+	// Stopwords are dropped from titles before slugifying.
+	Stopwords map[string]bool
+	// DefaultType is used when no type is given or inferred.
+	DefaultType IssueType
+	// Types lists the valid branch types and the label rules that select them.
+	Types *Registry
+	// Policy, if set, is an extra rule branch names must follow.
+	Policy *Policy
 }
 
-// AllIssueTypeStrings returns all valid issue types as strings.
-func AllIssueTypeStrings() []string {
-	types := AllIssueTypes()
-	result := make([]string, len(types))
-	for i, t := range types {
-		result[i] = string(t)
+// DefaultConvention returns the built-in naming convention.
+func DefaultConvention() Convention {
+	return Convention{
+		Format:        MustParseTemplate(DefaultFormat),
+		LegacyFormats: legacyTemplates(DefaultLegacyFormats),
+		Prefix:        "GH",
+		Keys:          issue.DefaultScheme(),
+		SlugMaxLength: 60,
+		DefaultType:   Feature,
+		Types:         DefaultRegistry(),
 	}
-	return result
 }
 
-// ValidIssueType checks if the given string is a valid issue type.
-func ValidIssueType(s string) bool {
-	for _, t := range AllIssueTypes() {
-		if string(t) == s {
-			return true
-		}
+func legacyTemplates(formats []string) []*Template {
+	templates := make([]*Template, len(formats))
+	for i, f := range formats {
+		templates[i] = MustParseTemplate(f)
 	}
-	return false
+	return templates
 }
 
-var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+// GenerateName renders the branch name for the given fields using the
+// convention's format. The prefix is only rendered alongside an issue key.
+// With several issues the IDs are joined, e.g. "GH-12-15", and the prefix of
+// the first issue is used. When the title yields no usable slug,
+// "issue-<id>" is used instead.
+// Slugs are truncated on word boundaries to fit SlugMaxLength, any "{slug:N}"
+// limit in the format and MaxLength. The rest of the name is never cut, so a
+// name may still exceed MaxLength; Validate reports it.
+func (c Convention) GenerateName(f Fields) string {
+	format := c.Format
+	if format == nil {
+		format = MustParseTemplate(DefaultFormat)
+	}
+
+	words := c.titleWords(f.Title)
+	if len(words) == 0 && len(f.Keys) > 0 {
+		words = []string{"issue", f.Keys[0].ID}
+	}
+	slugMax := minLength(c.SlugMaxLength, format.maxLength("slug"))
+
+	values := map[string]string{
+		"type":      string(f.Type),
+		"slug":      joinWords(words, slugMax),
+		"login":     slugify(f.Login, 0),
+		"milestone": slugify(f.Milestone, 0),
+	}
+	if len(f.Keys) > 0 {
+		values["number"] = keyIDs(f.Keys)
+		values["prefix"] = c.keyPrefix(f.Keys[0])
+		values["key"] = joinKey(values["prefix"], values["number"])
+	}
+	if !f.Date.IsZero() {
+		values["date"] = f.Date.Format("2006-01-02")
+	}
+
+	name := format.execute(values)
+	if c.MaxLength <= 0 || runeLen(name) <= c.MaxLength {
+		return name
+	}
+
+	// Shorten the slug by the overflow. Cutting anything else could change
+	// the issue number, so a name that is still too long is left for
+	// Validate to reject.
+	overflow := runeLen(name) - c.MaxLength
+	if limit := runeLen(values["slug"]) - overflow; limit > 0 {
+		values["slug"] = joinWords(words, limit)
+	} else {
+		values["slug"] = ""
+	}
+	return format.execute(values)
+}
 
-// GenerateName generates a branch name from issue type, number, and title.
-// Format: <type>/GH-<issue-number>-<slugified-title>
-func GenerateName(issueType IssueType, issueNumber int, title string) string {
-	slug := slugify(title)
-	if issueNumber > 0 {
-		return fmt.Sprintf("%s/GH-%d-%s", issueType, issueNumber, slug)
+// Key renders issue keys as the {key} placeholder does, e.g. "GH-42" or
+// "GH-12-15". It returns an empty string when there are no keys.
+func (c Convention) Key(keys []issue.Key) string {
+	if len(keys) == 0 {
+		return ""
 	}
-	return fmt.Sprintf("%s/%s", issueType, slug)
+	return joinKey(c.keyPrefix(keys[0]), keyIDs(keys))
 }
 
-func slugify(s string) string {
-	s = strings.ToLower(s)
-	s = nonAlphanumeric.ReplaceAllString(s, "-")
-	s = strings.Trim(s, "-")
-	// Limit length
-	if len(s) > 60 {
-		s = s[:60]
-		// Don't end on a hyphen
-		s = strings.TrimRight(s, "-")
-	}
-	return s
+// keyIDs joins the IDs of the keys with "-".
+func keyIDs(keys []issue.Key) string {
+	ids := make([]string, len(keys))
+	for i, k := range keys {
+		ids[i] = k.ID
+	}
+	return strings.Join(ids, "-")
+}
+
+// keyPrefix returns the prefix rendered before the key's ID. GitHub issues
+// of another repository use its owner and name joined by "+", e.g.
+// "acme+product-123", so Parse can tell which repository they belong to.
+func (c Convention) keyPrefix(k issue.Key) string {
+	if repo := k.Repo(); repo != "" {
+		return strings.ToLower(strings.Replace(repo, "/", repoSeparator, 1))
+	}
+	if k.IsGitHub() {
+		return c.Prefix
+	}
+	return k.Project
+}
+
+// repoSeparator joins the owner and name of another repository in a key
+// prefix. Neither can contain it.
+const repoSeparator = "+"
+
+// joinKey joins a prefix and an ID into a key such as "GH-42", or returns the
+// bare ID when the prefix is empty.
+func joinKey(prefix, id string) string {
+	return strings.Trim(prefix+"-"+id, "-")
+}
+
+// titleWords splits a title into slug words, dropping stopwords unless that
+// would leave nothing.
+func (c Convention) titleWords(title string) []string {
+	words := slugWords(title)
+	if len(c.Stopwords) == 0 {
+		return words
+	}
+	kept := make([]string, 0, len(words))
+	for _, w := range words {
+		if !c.Stopwords[w] {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == 0 {
+		return words
+	}
+	return kept
+}
+
+// minLength returns the smaller positive limit, treating 0 as no limit.
+func minLength(a, b int) int {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
 }
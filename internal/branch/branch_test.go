@@ -0,0 +1,154 @@
+package branch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+)
+
+func TestGenerateName(t *testing.T) {
+	gh := func(ids ...string) []issue.Key {
+		keys := make([]issue.Key, len(ids))
+		for i, id := range ids {
+			keys[i] = issue.Key{Tracker: issue.GitHub, ID: id}
+		}
+		return keys
+	}
+	jira := issue.Scheme{Tracker: "jira", Project: "PAY"}
+	date := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		conv   func(c *Convention)
+		fields Fields
+		want   string
+	}{
+		{
+			name:   "default format",
+			fields: Fields{Type: Feature, Keys: gh("42"), Title: "Add login page"},
+			want:   "feature/GH-42-add-login-page",
+		},
+		{
+			name:   "several issues",
+			fields: Fields{Type: Bugfix, Keys: gh("12", "15"), Title: "Fix crash on start"},
+			want:   "bugfix/GH-12-15-fix-crash-on-start",
+		},
+		{
+			name:   "issue of another repository",
+			fields: Fields{Type: Feature, Keys: []issue.Key{{Tracker: issue.GitHub, Project: "acme/product", ID: "123"}}, Title: "Export CSV"},
+			want:   "feature/acme+product-123-export-csv",
+		},
+		{
+			name:   "external tracker",
+			conv:   func(c *Convention) { c.Keys = jira },
+			fields: Fields{Type: Feature, Keys: []issue.Key{{Tracker: "jira", Project: "PAY", ID: "881"}}, Title: "Refund partial payments"},
+			want:   "feature/PAY-881-refund-partial-payments",
+		},
+		{
+			name:   "no issue",
+			fields: Fields{Type: Chore, Title: "Bump dependencies"},
+			want:   "chore/bump-dependencies",
+		},
+		{
+			name:   "title without usable words",
+			fields: Fields{Type: Feature, Keys: gh("42"), Title: "🚀 ✨"},
+			want:   "feature/GH-42-issue-42",
+		},
+		{
+			name:   "accents and punctuation",
+			fields: Fields{Type: Feature, Keys: gh("7"), Title: "Añadir validación: ¡email & teléfono!"},
+			want:   "feature/GH-7-anadir-validacion-email-telefono",
+		},
+		{
+			name:   "stopwords",
+			conv:   func(c *Convention) { c.Stopwords = map[string]bool{"the": true, "a": true, "of": true} },
+			fields: Fields{Type: Docs, Keys: gh("9"), Title: "Document the usage of a cache"},
+			want:   "docs/GH-9-document-usage-cache",
+		},
+		{
+			name:   "only stopwords",
+			conv:   func(c *Convention) { c.Stopwords = map[string]bool{"the": true, "end": true} },
+			fields: Fields{Type: Feature, Keys: gh("9"), Title: "The End"},
+			want:   "feature/GH-9-the-end",
+		},
+		{
+			name:   "slug max length keeps whole words",
+			conv:   func(c *Convention) { c.SlugMaxLength = 20 },
+			fields: Fields{Type: Refactor, Keys: gh("3"), Title: "Refactor payment processing module"},
+			want:   "refactor/GH-3-refactor-payment",
+		},
+		{
+			name:   "branch max length shortens the slug",
+			conv:   func(c *Convention) { c.MaxLength = 30 },
+			fields: Fields{Type: Feature, Keys: gh("42"), Title: "Refactor payment processing module"},
+			want:   "feature/GH-42-refactor-payment",
+		},
+		{
+			name:   "branch max length never cuts the key",
+			conv:   func(c *Convention) { c.MaxLength = 10 },
+			fields: Fields{Type: Feature, Keys: gh("4242"), Title: "Refactor payment processing module"},
+			want:   "feature/GH-4242",
+		},
+		{
+			name:   "Cyrillic",
+			fields: Fields{Type: Bugfix, Keys: gh("8"), Title: "Исправить вход"},
+			want:   "bugfix/GH-8-ispravit-vkhod",
+		},
+		{
+			name: "custom format",
+			conv: func(c *Convention) {
+				c.Format = MustParseTemplate("{login}/{date}/{key}-{slug:12}")
+			},
+			fields: Fields{Type: Feature, Keys: gh("42"), Title: "Refactor payment processing", Login: "OctoCat", Date: date},
+			want:   "octocat/2024-03-05/GH-42-refactor",
+		},
+		{
+			name: "empty milestone",
+			conv: func(c *Convention) {
+				c.Format = MustParseTemplate("{type}/{milestone}/{number}-{slug}")
+			},
+			fields: Fields{Type: Feature, Keys: gh("42"), Title: "Add login"},
+			want:   "feature/42-add-login",
+		},
+		{
+			name: "milestone",
+			conv: func(c *Convention) {
+				c.Format = MustParseTemplate("{type}/{milestone}/{number}-{slug}")
+			},
+			fields: Fields{Type: Feature, Keys: gh("42"), Title: "Add login", Milestone: "Sprint 12"},
+			want:   "feature/sprint-12/42-add-login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConvention()
+			if tt.conv != nil {
+				tt.conv(&c)
+			}
+			if got := c.GenerateName(tt.fields); got != tt.want {
+				t.Errorf("GenerateName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	c := DefaultConvention()
+	tests := []struct {
+		keys []issue.Key
+		want string
+	}{
+		{nil, ""},
+		{[]issue.Key{issue.GitHubKey(42)}, "GH-42"},
+		{[]issue.Key{issue.GitHubKey(12), issue.GitHubKey(15)}, "GH-12-15"},
+		{[]issue.Key{{Tracker: "jira", Project: "PAY", ID: "881"}}, "PAY-881"},
+	}
+
+	for _, tt := range tests {
+		if got := c.Key(tt.keys); got != tt.want {
+			t.Errorf("Key(%v) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
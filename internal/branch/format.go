@@ -0,0 +1,216 @@
+package branch
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+)
+
+// DefaultFormat is the built-in branch name template.
+const DefaultFormat = "{type}/{prefix}-{number}-{slug}"
+
+// Placeholders lists the names accepted inside a format template.
+var Placeholders = []string{"type", "prefix", "number", "key", "slug", "login", "milestone", "date"}
+
+// placeholderAliases maps alternative placeholder names to their canonical name.
+var placeholderAliases = map[string]string{
+	"user": "login",
+}
+
+// Fields holds the values substituted into a format template.
+type Fields struct {
+	Type IssueType
+	// Keys are the issues the branch covers; the first one is the primary.
+	Keys      []issue.Key
+	Title     string
+	Login     string
+	Milestone string
+	Date      time.Time
+}
+
+// Template is a parsed branch name format such as "{type}/{prefix}-{number}-{slug}".
+// A placeholder may carry a maximum length in runes, e.g. "{slug:40}".
+type Template struct {
+	raw   string
+	parts []templatePart
+}
+
+type templatePart struct {
+	literal string
+	name    string
+	max     int
+}
+
+// ParseTemplate parses a branch name format.
+func ParseTemplate(format string) (*Template, error) {
+	t := &Template{raw: format}
+	rest := format
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("unexpected %q at offset %d", "}", len(format)-len(rest)+open)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder at offset %d", len(format)-len(rest)+open)
+		}
+		p, err := parsePlaceholder(rest[open+1 : open+end])
+		if err != nil {
+			return nil, err
+		}
+		t.parts = append(t.parts, p)
+		rest = rest[open+end+1:]
+	}
+
+	if !t.Uses("slug") && !t.Uses("number") && !t.Uses("key") {
+		return nil, fmt.Errorf("format must contain {slug}, {number} or {key}")
+	}
+	// Literal parts end up in every name, so they must follow git's rules
+	if err := ValidateRefName(t.execute(sampleValues)); err != nil {
+		return nil, fmt.Errorf("format does not give valid branch names: %w", err)
+	}
+	return t, nil
+}
+
+// SampleName renders the template with typical placeholder values and the
+// given key prefix, giving a name to check against git's rules.
+func (t *Template) SampleName(prefix string) string {
+	values := maps.Clone(sampleValues)
+	values["prefix"] = prefix
+	values["key"] = joinKey(prefix, values["number"])
+	return t.execute(values)
+}
+
+// sampleValues are typical placeholder values used to check that a template
+// renders valid branch names.
+var sampleValues = map[string]string{
+	"type":      "feature",
+	"prefix":    "GH",
+	"number":    "42",
+	"key":       "GH-42",
+	"slug":      "add-login",
+	"login":     "octocat",
+	"milestone": "v1",
+	"date":      "2006-01-02",
+}
+
+func parsePlaceholder(s string) (templatePart, error) {
+	name, maxStr, hasMax := strings.Cut(s, ":")
+	if canonical, ok := placeholderAliases[name]; ok {
+		name = canonical
+	}
+	known := false
+	for _, p := range Placeholders {
+		if p == name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return templatePart{}, fmt.Errorf("unknown placeholder {%s} (valid: %s)", s, strings.Join(Placeholders, ", "))
+	}
+
+	p := templatePart{name: name}
+	if hasMax {
+		if name == "number" || name == "key" {
+			// A cut issue number would point at another issue
+			return templatePart{}, fmt.Errorf("{%s} cannot have a max length", name)
+		}
+		n, err := strconv.Atoi(maxStr)
+		if err != nil || n <= 0 {
+			return templatePart{}, fmt.Errorf("invalid max length in {%s}: must be a positive integer", s)
+		}
+		p.max = n
+	}
+	return p, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics on error.
+func MustParseTemplate(format string) *Template {
+	t, err := ParseTemplate(format)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the format the template was parsed from.
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Uses reports whether the template contains the named placeholder.
+func (t *Template) Uses(name string) bool {
+	for _, p := range t.parts {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maxLength returns the length limit of the named placeholder, or 0 if the
+// placeholder is unused or unlimited. With several occurrences the smallest
+// limit wins.
+func (t *Template) maxLength(name string) int {
+	limit := 0
+	for _, p := range t.parts {
+		if p.name == name {
+			limit = minLength(limit, p.max)
+		}
+	}
+	return limit
+}
+
+// execute renders the template with already-sanitized placeholder values.
+// Separators left dangling by empty values are removed.
+func (t *Template) execute(values map[string]string) string {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		if p.name == "" {
+			sb.WriteString(p.literal)
+			continue
+		}
+		v := values[p.name]
+		if p.max > 0 {
+			v = truncateRunes(v, p.max)
+		}
+		sb.WriteString(v)
+	}
+	return tidySeparators(sb.String())
+}
+
+// tidySeparators drops empty path segments and collapses or trims runs of
+// "-", "_" and "." left behind by empty placeholders.
+func tidySeparators(name string) string {
+	var segments []string
+	for _, seg := range strings.Split(name, "/") {
+		for strings.Contains(seg, "--") {
+			seg = strings.ReplaceAll(seg, "--", "-")
+		}
+		seg = strings.Trim(seg, "-_.")
+		if seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimRight(string(r[:n]), "-")
+}
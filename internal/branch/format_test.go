@@ -0,0 +1,73 @@
+package branch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		format  string
+		uses    []string
+		wantErr string
+	}{
+		{format: DefaultFormat, uses: []string{"type", "prefix", "number", "slug"}},
+		{format: "{user}/{number}-{slug:30}", uses: []string{"login", "number", "slug"}},
+		{format: "{type}/{key}", uses: []string{"type", "key"}},
+		{format: "{date}_{slug}", uses: []string{"date", "slug"}},
+		{format: "team/{milestone}/{key}-{slug}", uses: []string{"milestone", "key", "slug"}},
+		{format: "{type}/{title}", wantErr: "unknown placeholder {title}"},
+		{format: "{type}/{slug", wantErr: "unclosed placeholder at offset 7"},
+		{format: "{type}/slug}", wantErr: `unexpected "}" at offset 11`},
+		{format: "{type}/{prefix}", wantErr: "must contain {slug}, {number} or {key}"},
+		{format: "{type}/{slug:0}", wantErr: "invalid max length in {slug:0}"},
+		{format: "{type}/{slug:abc}", wantErr: "invalid max length in {slug:abc}"},
+		{format: "{type}/{number:3}-{slug}", wantErr: "{number} cannot have a max length"},
+		{format: "{key:5}-{slug}", wantErr: "{key} cannot have a max length"},
+		{format: "{type}~{slug}", wantErr: "cannot contain '~'"},
+		{format: "{type} {slug}", wantErr: "cannot contain spaces"},
+		{format: "{type}/{slug}.lock", wantErr: `cannot end with ".lock"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseTemplate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+			if tmpl.String() != tt.format {
+				t.Errorf("String() = %q, want %q", tmpl.String(), tt.format)
+			}
+			for _, name := range tt.uses {
+				if !tmpl.Uses(name) {
+					t.Errorf("Uses(%q) = false, want true", name)
+				}
+			}
+		})
+	}
+}
+
+func TestTidySeparators(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"feature/GH-42-add-login", "feature/GH-42-add-login"},
+		{"feature/-42-add-login", "feature/42-add-login"},
+		{"feature//GH-42", "feature/GH-42"},
+		{"/feature/GH-42-", "feature/GH-42"},
+		{"octocat/--add-login", "octocat/add-login"},
+		{"v1._add", "v1._add"},
+	}
+
+	for _, tt := range tests {
+		if got := tidySeparators(tt.in); got != tt.want {
+			t.Errorf("tidySeparators(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
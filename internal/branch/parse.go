@@ -0,0 +1,185 @@
+package branch
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+)
+
+// DefaultLegacyFormats lists formats recognised by Parse in addition to the
+// configured one: the original built-in format, the "type/number-title" form
+// and branches without an issue number.
+var DefaultLegacyFormats = []string{
+	"{type}/GH-{number}-{slug}",
+	"{type}/{number}-{slug}",
+	"{type}/{slug}",
+}
+
+// Parsed holds the parts recovered from a branch name.
+type Parsed struct {
+	// Type is the branch type as written in the name, resolved to its
+	// canonical name when it matches a known type or alias.
+	Type IssueType
+	// Keys are the issues named in the branch, in order. Branch names cannot
+	// tell a second issue number from a slug that starts with digits, so
+	// callers should prefer a recorded issue list when one is available.
+	Keys []issue.Key
+	Slug string
+}
+
+// placeholderPatterns are the regular expressions matched by each
+// placeholder when parsing. For GitHub issues the prefix is matched
+// literally; {key} is expanded into its prefix and number.
+var placeholderPatterns = map[string]string{
+	"type":      `[^/]+?`,
+	"prefix":    `[A-Za-z][A-Za-z0-9_]*`,
+	"number":    `\d+(?:-\d+)*`,
+	"slug":      `[^/]+`,
+	"login":     `[^/]+?`,
+	"milestone": `[^/]+?`,
+	"date":      `\d{4}-\d{2}-\d{2}`,
+}
+
+// repoPrefixPattern matches the key prefix of a GitHub issue of another
+// repository, "owner+repo". It is lazy so that a repository name does not
+// swallow the issue numbers that follow it.
+const repoPrefixPattern = `[A-Za-z0-9][A-Za-z0-9-]*\+[A-Za-z0-9_.-]+?`
+
+// sentinel marks a placeholder in a rendered template; it survives
+// tidySeparators untouched.
+const sentinel = "\x00"
+
+// Parse recovers the type, issue number and slug from a branch name, trying
+// the configured format first and then each legacy format. It reports false
+// if no format matches.
+func (c Convention) Parse(name string) (Parsed, bool) {
+	formats := []*Template{c.Format}
+	if c.Format == nil {
+		formats[0] = MustParseTemplate(DefaultFormat)
+	}
+	formats = append(formats, c.LegacyFormats...)
+
+	for _, t := range formats {
+		for _, re := range c.patterns(t) {
+			if p, ok := c.match(re, name); ok {
+				return p, true
+			}
+		}
+	}
+	return Parsed{}, false
+}
+
+func (c Convention) match(re *regexp.Regexp, name string) (Parsed, bool) {
+	m := re.FindStringSubmatch(name)
+	if m == nil {
+		return Parsed{}, false
+	}
+
+	var p Parsed
+	if i := re.SubexpIndex("type"); i > 0 {
+		p.Type = IssueType(m[i])
+		if c.Types != nil {
+			if t, ok := c.Types.Resolve(m[i]); ok {
+				p.Type = t
+			}
+		}
+	}
+	if i := re.SubexpIndex("number"); i > 0 {
+		j := re.SubexpIndex("prefix")
+		for _, id := range strings.Split(m[i], "-") {
+			var key issue.Key
+			switch {
+			case j > 0 && strings.Contains(m[j], repoSeparator):
+				// GitHub issue of another repository
+				key = issue.Key{Tracker: issue.GitHub, Project: strings.Replace(m[j], repoSeparator, "/", 1), ID: id}
+			case j > 0 && strings.EqualFold(m[j], c.Prefix):
+				// GitHub issue in a repository that also uses an external tracker
+				key = issue.Key{Tracker: issue.GitHub, ID: id}
+			case j > 0:
+				key = issue.Key{Tracker: c.Keys.Tracker, Project: strings.ToUpper(m[j]), ID: id}
+			default:
+				key, _ = c.Keys.ParseKey(id)
+			}
+			if !key.IsZero() {
+				p.Keys = append(p.Keys, key)
+			}
+		}
+	}
+	if i := re.SubexpIndex("slug"); i > 0 {
+		p.Slug = m[i]
+	}
+	return p, true
+}
+
+// patterns builds the regular expressions matching names generated by t.
+// Names may have been generated with or without a milestone, so templates
+// using {milestone} yield a pattern for each case. Templates rendering the
+// key prefix also yield patterns for issues of other repositories, whose
+// prefix names the repository.
+func (c Convention) patterns(t *Template) []*regexp.Regexp {
+	type variant struct {
+		empty     map[string]bool
+		otherRepo bool
+	}
+	variants := []variant{{}}
+	if t.Uses("milestone") {
+		variants = append(variants, variant{empty: map[string]bool{"milestone": true}})
+	}
+	if t.Uses("prefix") || t.Uses("key") {
+		for _, v := range slices.Clone(variants) {
+			variants = append(variants, variant{empty: v.empty, otherRepo: true})
+		}
+	}
+
+	var res []*regexp.Regexp
+	for _, v := range variants {
+		values := make(map[string]string)
+		for _, name := range Placeholders {
+			if !v.empty[name] {
+				values[name] = sentinel + name + sentinel
+			}
+		}
+		if c.Keys.IsGitHub() && !v.otherRepo {
+			values["prefix"] = c.Prefix
+		}
+		pattern := func(name string) string {
+			if name == "prefix" && v.otherRepo {
+				return repoPrefixPattern
+			}
+			return placeholderPatterns[name]
+		}
+		values["key"] = joinKey(values["prefix"], values["number"])
+
+		// Render without placeholder length limits so every sentinel is intact.
+		unlimited := &Template{raw: t.raw, parts: make([]templatePart, len(t.parts))}
+		for i, p := range t.parts {
+			unlimited.parts[i] = templatePart{literal: p.literal, name: p.name}
+		}
+		rendered := unlimited.execute(values)
+
+		var sb strings.Builder
+		sb.WriteString("^")
+		seen := make(map[string]bool)
+		for i, piece := range strings.Split(rendered, sentinel) {
+			if i%2 == 0 {
+				sb.WriteString(regexp.QuoteMeta(piece))
+				continue
+			}
+			if seen[piece] {
+				sb.WriteString("(?:" + pattern(piece) + ")")
+				continue
+			}
+			seen[piece] = true
+			sb.WriteString("(?P<" + piece + ">" + pattern(piece) + ")")
+		}
+		sb.WriteString("$")
+
+		re, err := regexp.Compile(sb.String())
+		if err == nil {
+			res = append(res, re)
+		}
+	}
+	return res
+}
@@ -0,0 +1,194 @@
+package branch
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+)
+
+func TestParse(t *testing.T) {
+	jira := func(c *Convention) {
+		c.Format = MustParseTemplate("{type}/{key}-{slug}")
+		c.Keys = issue.Scheme{Tracker: "jira", Project: "PAY"}
+	}
+
+	tests := []struct {
+		name   string
+		conv   func(c *Convention)
+		branch string
+		ok     bool
+		typ    IssueType
+		keys   []string
+		slug   string
+	}{
+		{
+			name:   "default format",
+			branch: "feature/GH-42-add-login",
+			ok:     true, typ: Feature, keys: []string{"#42"}, slug: "add-login",
+		},
+		{
+			name:   "several issues",
+			branch: "bugfix/GH-12-15-fix-crash",
+			ok:     true, typ: Bugfix, keys: []string{"#12", "#15"}, slug: "fix-crash",
+		},
+		{
+			name:   "type alias",
+			branch: "feat/GH-7-dark-mode",
+			ok:     true, typ: Feature, keys: []string{"#7"}, slug: "dark-mode",
+		},
+		{
+			name:   "unknown type is kept",
+			branch: "spike/GH-7-dark-mode",
+			ok:     true, typ: "spike", keys: []string{"#7"}, slug: "dark-mode",
+		},
+		{
+			name:   "legacy number-title format",
+			branch: "feature/42-add-login",
+			ok:     true, typ: Feature, keys: []string{"#42"}, slug: "add-login",
+		},
+		{
+			name:   "without an issue",
+			branch: "chore/bump-dependencies",
+			ok:     true, typ: Chore, slug: "bump-dependencies",
+		},
+		{
+			name:   "no type",
+			branch: "main",
+			ok:     false,
+		},
+		{
+			name:   "external tracker",
+			conv:   jira,
+			branch: "feature/PAY-881-refund-payments",
+			ok:     true, typ: Feature, keys: []string{"PAY-881"}, slug: "refund-payments",
+		},
+		{
+			name:   "GitHub issue next to an external tracker",
+			conv:   jira,
+			branch: "bugfix/GH-5-typo",
+			ok:     true, typ: Bugfix, keys: []string{"#5"}, slug: "typo",
+		},
+		{
+			name:   "issue of another repository",
+			branch: "feature/acme+product-7-cross-thing",
+			ok:     true, typ: Feature, keys: []string{"acme/product#7"}, slug: "cross-thing",
+		},
+		{
+			name:   "issue of another repository with an empty prefix",
+			conv:   func(c *Convention) { c.Prefix = "" },
+			branch: "feature/acme+product-7-cross-thing",
+			ok:     true, typ: Feature, keys: []string{"acme/product#7"}, slug: "cross-thing",
+		},
+		{
+			name:   "issue of another repository next to an external tracker",
+			conv:   jira,
+			branch: "feature/acme+web.app-7-cross-thing",
+			ok:     true, typ: Feature, keys: []string{"acme/web.app#7"}, slug: "cross-thing",
+		},
+		{
+			name: "custom format with milestone",
+			conv: func(c *Convention) {
+				c.Format = MustParseTemplate("{login}/{milestone}/{key}-{slug}")
+			},
+			branch: "octocat/sprint-12/GH-42-add-login",
+			ok:     true, keys: []string{"#42"}, slug: "add-login",
+		},
+		{
+			name: "custom format without milestone",
+			conv: func(c *Convention) {
+				c.Format = MustParseTemplate("{login}/{milestone}/{key}-{slug}")
+			},
+			branch: "octocat/GH-42-add-login",
+			ok:     true, keys: []string{"#42"}, slug: "add-login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConvention()
+			if tt.conv != nil {
+				tt.conv(&c)
+			}
+			got, ok := c.Parse(tt.branch)
+			if ok != tt.ok {
+				t.Fatalf("Parse(%q) ok = %v, want %v", tt.branch, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if got.Type != tt.typ {
+				t.Errorf("Type = %q, want %q", got.Type, tt.typ)
+			}
+			var keys []string
+			for _, k := range got.Keys {
+				keys = append(keys, k.String())
+			}
+			if !slices.Equal(keys, tt.keys) {
+				t.Errorf("Keys = %q, want %q", keys, tt.keys)
+			}
+			if got.Slug != tt.slug {
+				t.Errorf("Slug = %q, want %q", got.Slug, tt.slug)
+			}
+		})
+	}
+}
+
+func TestParseGeneratedNames(t *testing.T) {
+	c := DefaultConvention()
+	keys := []issue.Key{issue.GitHubKey(12), issue.GitHubKey(15)}
+	name := c.GenerateName(Fields{Type: Hotfix, Keys: keys, Title: "Crash on login"})
+
+	got, ok := c.Parse(name)
+	if !ok || got.Type != Hotfix || !slices.Equal(got.Keys, keys) {
+		t.Errorf("Parse(%q) = %+v, %v, want the generated type and keys", name, got, ok)
+	}
+}
+
+func TestParseGeneratedNamesOtherRepository(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []issue.Key
+		// want is the keys Parse returns; repositories come back in lower case
+		want []issue.Key
+	}{
+		{
+			name: "one issue",
+			keys: []issue.Key{{Tracker: issue.GitHub, Project: "acme/product", ID: "7"}},
+		},
+		{
+			name: "several issues",
+			keys: []issue.Key{
+				{Tracker: issue.GitHub, Project: "acme/product", ID: "7"},
+				{Tracker: issue.GitHub, Project: "acme/product", ID: "9"},
+			},
+		},
+		{
+			// The owner is kept, so repositories with the same name do not collide
+			name: "same name under another owner",
+			keys: []issue.Key{{Tracker: issue.GitHub, Project: "octo/product", ID: "7"}},
+		},
+		{
+			name: "owner and name with separators",
+			keys: []issue.Key{{Tracker: issue.GitHub, Project: "Big-Corp/Web_App.js", ID: "12"}},
+			want: []issue.Key{{Tracker: issue.GitHub, Project: "big-corp/web_app.js", ID: "12"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConvention()
+			name := c.GenerateName(Fields{Type: Feature, Keys: tt.keys, Title: "Cross thing"})
+			want := tt.want
+			if want == nil {
+				want = tt.keys
+			}
+
+			got, ok := c.Parse(name)
+			if !ok || got.Type != Feature || !slices.Equal(got.Keys, want) || got.Slug != "cross-thing" {
+				t.Errorf("Parse(%q) = %+v, %v, want keys %v", name, got, ok, want)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,104 @@
+package branch
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations romanises letters that do not decompose into an ASCII
+// base letter plus combining marks.
+var transliterations = map[rune]string{
+	// Latin
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'þ': "th",
+	'ł': "l", 'ı': "i", 'ŋ': "ng", 'ħ': "h", 'ĸ': "k", 'ſ': "s",
+
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// foldAccents decomposes s and strips combining marks, so "á" becomes "a"
+// and full-width forms become their ASCII equivalent.
+var foldAccents = transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// transliterate folds accents and romanises Latin, Greek and Cyrillic
+// letters. Runes from other scripts are kept and left for the caller to drop.
+func transliterate(s string) string {
+	folded, _, err := transform.String(foldAccents, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+
+	var sb strings.Builder
+	for _, r := range folded {
+		if t, ok := transliterations[r]; ok {
+			sb.WriteString(t)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// slugWords transliterates s and splits it into lowercase ASCII words.
+// Characters that cannot be transliterated (e.g. CJK or emoji) act as word
+// separators.
+func slugWords(s string) []string {
+	return strings.FieldsFunc(transliterate(s), func(r rune) bool {
+		return r >= unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+}
+
+// slugify turns s into a lowercase ASCII slug of words joined by hyphens.
+// maxLen caps the slug length in runes; 0 means no limit. The result is empty
+// when nothing usable remains.
+func slugify(s string, maxLen int) string {
+	return joinWords(slugWords(s), maxLen)
+}
+
+// joinWords joins words with hyphens, keeping as many whole words as fit in
+// maxLen runes. A first word longer than maxLen is cut. A maxLen of 0 means
+// no limit; a negative maxLen yields an empty string.
+func joinWords(words []string, maxLen int) string {
+	if maxLen < 0 {
+		return ""
+	}
+	var sb strings.Builder
+	length := 0
+	for _, w := range words {
+		n := runeLen(w)
+		if length > 0 {
+			n++
+		}
+		if maxLen > 0 && length+n > maxLen {
+			if length == 0 {
+				return truncateRunes(w, maxLen)
+			}
+			break
+		}
+		if length > 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteString(w)
+		length += n
+	}
+	return sb.String()
+}
+
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
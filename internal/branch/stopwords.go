@@ -0,0 +1,25 @@
+package branch
+
+// DefaultStopwords holds the built-in stopword lists, keyed by language code.
+var DefaultStopwords = map[string][]string{
+	"en": {
+		"a", "an", "and", "are", "as", "at", "be", "by", "for", "from", "in", "into",
+		"is", "it", "of", "on", "or", "that", "the", "this", "to", "with",
+	},
+	"es": {
+		"a", "al", "con", "de", "del", "el", "en", "es", "la", "las", "lo", "los",
+		"para", "por", "que", "se", "su", "un", "una", "y",
+	},
+	"fr": {
+		"a", "au", "aux", "avec", "ce", "d", "dans", "de", "des", "du", "en", "et",
+		"l", "la", "le", "les", "par", "pour", "sur", "un", "une",
+	},
+	"de": {
+		"am", "auf", "das", "dem", "den", "der", "des", "die", "ein", "eine", "einen",
+		"fur", "im", "in", "ist", "mit", "und", "von", "zu", "zum", "zur",
+	},
+	"pt": {
+		"a", "ao", "as", "com", "da", "das", "de", "do", "dos", "e", "em", "na",
+		"no", "o", "os", "para", "por", "um", "uma",
+	},
+}
@@ -0,0 +1,253 @@
+package branch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IssueType represents the type of issue for branch naming.
+type IssueType string
+
+const (
+	Feature  IssueType = "feature"
+	Bugfix   IssueType = "bugfix"
+	Hotfix   IssueType = "hotfix"
+	Release  IssueType = "release"
+	Chore    IssueType = "chore"
+	Docs     IssueType = "docs"
+	Refactor IssueType = "refactor"
+	Test     IssueType = "test"
+	Internal IssueType = "internal"
+)
+
+// TypeDef describes a branch type and the alternative names accepted for it.
+type TypeDef struct {
+	Name    IssueType
+	Aliases []string
+}
+
+// MatchKind selects how a LabelRule pattern is compared to a label.
+type MatchKind string
+
+const (
+	// MatchExact matches a label equal to the pattern.
+	MatchExact MatchKind = "exact"
+	// MatchGlob matches a label against a pattern where * matches any run of
+	// characters (including "/") and ? matches a single character.
+	MatchGlob MatchKind = "glob"
+	// MatchRegex matches a label containing a match of the regular expression.
+	MatchRegex MatchKind = "regex"
+)
+
+// LabelRule maps issue labels to a branch type. All matches ignore case.
+type LabelRule struct {
+	Match   MatchKind
+	Pattern string
+	Type    IssueType
+
+	re *regexp.Regexp
+}
+
+// Compile validates the rule and prepares it for matching.
+func (r *LabelRule) Compile() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern must not be empty")
+	}
+	var expr string
+	switch r.Match {
+	case MatchExact:
+		expr = "^" + regexp.QuoteMeta(r.Pattern) + "$"
+	case MatchGlob:
+		expr = "^" + globToRegex(r.Pattern) + "$"
+	case MatchRegex:
+		expr = r.Pattern
+	default:
+		return fmt.Errorf("unknown match kind %q (want exact, glob or regex)", r.Match)
+	}
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+	r.re = re
+	return nil
+}
+
+// Matches reports whether the rule matches the label.
+func (r *LabelRule) Matches(label string) bool {
+	if r.re == nil {
+		if err := r.Compile(); err != nil {
+			return false
+		}
+	}
+	return r.re.MatchString(label)
+}
+
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	for _, c := range glob {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// Registry holds the known branch types and the rules used to infer a type
+// from issue labels.
+type Registry struct {
+	types []TypeDef
+	rules []LabelRule
+}
+
+// NewRegistry builds a registry from type definitions and ordered label rules.
+// Rule types may be given by alias. It fails if a name or alias is declared twice or a rule refers to an
+// unknown type.
+func NewRegistry(types []TypeDef, rules []LabelRule) (*Registry, error) {
+	r := &Registry{types: types}
+
+	names := make(map[string]IssueType)
+	for _, t := range types {
+		for _, n := range append([]string{string(t.Name)}, t.Aliases...) {
+			key := strings.ToLower(n)
+			if prev, ok := names[key]; ok {
+				return nil, fmt.Errorf("%q is declared by both %q and %q", n, prev, t.Name)
+			}
+			names[key] = t.Name
+		}
+	}
+
+	for i, rule := range rules {
+		if err := rule.Compile(); err != nil {
+			return nil, fmt.Errorf("label rule %d: %w", i, err)
+		}
+		t, ok := r.Resolve(string(rule.Type))
+		if !ok {
+			return nil, fmt.Errorf("label rule %d: unknown type %q", i, rule.Type)
+		}
+		// Rules may name a type by alias; Infer returns the canonical name
+		rule.Type = t
+		r.rules = append(r.rules, rule)
+	}
+	return r, nil
+}
+
+// DefaultTypes returns the built-in branch types.
+func DefaultTypes() []TypeDef {
+	return []TypeDef{
+		{Name: Feature, Aliases: []string{"feat"}},
+		{Name: Bugfix, Aliases: []string{"bug", "fix"}},
+		{Name: Hotfix},
+		{Name: Release},
+		{Name: Chore},
+		{Name: Docs, Aliases: []string{"doc"}},
+		{Name: Refactor},
+		{Name: Test, Aliases: []string{"tests"}},
+		{Name: Internal},
+	}
+}
+
+// DefaultLabelRules returns the built-in label rules. Each keyword matches a
+// label that starts or ends with it, so "bug" matches "bug" and "kind-bug".
+func DefaultLabelRules() []LabelRule {
+	keywords := []struct {
+		t  IssueType
+		kw string
+	}{
+		{Bugfix, "bug|fix"},
+		{Feature, "feature|enhancement"},
+		{Hotfix, "hotfix|urgent|critical"},
+		{Docs, "docs|documentation"},
+		{Refactor, "refactor"},
+		{Test, "test"},
+		{Chore, "chore|maintenance"},
+		{Internal, "internal"},
+	}
+	rules := make([]LabelRule, len(keywords))
+	for i, k := range keywords {
+		rules[i] = LabelRule{
+			Match:   MatchRegex,
+			Pattern: fmt.Sprintf("^(%s)|(%s)$", k.kw, k.kw),
+			Type:    k.t,
+		}
+	}
+	return rules
+}
+
+// DefaultRegistry returns the registry of built-in types and label rules.
+func DefaultRegistry() *Registry {
+	r, err := NewRegistry(DefaultTypes(), DefaultLabelRules())
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Types returns the definitions of all known types in display order.
+func (r *Registry) Types() []TypeDef {
+	return r.types
+}
+
+// Rules returns the label rules in evaluation order.
+func (r *Registry) Rules() []LabelRule {
+	return r.rules
+}
+
+// AllIssueTypes returns all valid issue types.
+func (r *Registry) AllIssueTypes() []IssueType {
+	result := make([]IssueType, len(r.types))
+	for i, t := range r.types {
+		result[i] = t.Name
+	}
+	return result
+}
+
+// AllIssueTypeStrings returns all valid issue types as strings.
+func (r *Registry) AllIssueTypeStrings() []string {
+	types := r.AllIssueTypes()
+	result := make([]string, len(types))
+	for i, t := range types {
+		result[i] = string(t)
+	}
+	return result
+}
+
+// Resolve returns the type whose name or alias equals s, ignoring case.
+func (r *Registry) Resolve(s string) (IssueType, bool) {
+	for _, t := range r.types {
+		if strings.EqualFold(string(t.Name), s) {
+			return t.Name, true
+		}
+		for _, a := range t.Aliases {
+			if strings.EqualFold(a, s) {
+				return t.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ValidIssueType checks if the given string is a valid issue type name or alias.
+func (r *Registry) ValidIssueType(s string) bool {
+	_, ok := r.Resolve(s)
+	return ok
+}
+
+// Infer returns the type of the first rule that matches any of the labels, or
+// an empty IssueType if no rule matches. Rules are tried in order, so earlier
+// rules take priority regardless of label order.
+func (r *Registry) Infer(labels []string) IssueType {
+	for i := range r.rules {
+		for _, label := range labels {
+			if r.rules[i].Matches(label) {
+				return r.rules[i].Type
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package branch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryInfer(t *testing.T) {
+	tests := []struct {
+		labels []string
+		want   IssueType
+	}{
+		{[]string{"bug"}, Bugfix},
+		{[]string{"kind-bug"}, Bugfix},
+		{[]string{"Enhancement"}, Feature},
+		{[]string{"documentation", "bug"}, Bugfix},
+		{[]string{"debugging"}, ""},
+		{[]string{"question"}, ""},
+		{nil, ""},
+	}
+
+	r := DefaultRegistry()
+	for _, tt := range tests {
+		if got := r.Infer(tt.labels); got != tt.want {
+			t.Errorf("Infer(%q) = %q, want %q", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	types := []TypeDef{
+		{Name: Feature, Aliases: []string{"feat"}},
+		{Name: Bugfix, Aliases: []string{"fix"}},
+	}
+
+	t.Run("rule by alias", func(t *testing.T) {
+		r, err := NewRegistry(types, []LabelRule{{Match: MatchGlob, Pattern: "type: fix*", Type: "fix"}})
+		if err != nil {
+			t.Fatalf("NewRegistry() error = %v", err)
+		}
+		if got := r.Infer([]string{"Type: Fixes"}); got != Bugfix {
+			t.Errorf("Infer() = %q, want the canonical %q", got, Bugfix)
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		types   []TypeDef
+		rules   []LabelRule
+		wantErr string
+	}{
+		{
+			name:    "duplicate alias",
+			types:   append(types, TypeDef{Name: "hotfix", Aliases: []string{"FIX"}}),
+			wantErr: `"FIX" is declared by both "bugfix" and "hotfix"`,
+		},
+		{
+			name:    "unknown rule type",
+			types:   types,
+			rules:   []LabelRule{{Match: MatchExact, Pattern: "docs", Type: "docs"}},
+			wantErr: `label rule 0: unknown type "docs"`,
+		},
+		{
+			name:    "invalid regex",
+			types:   types,
+			rules:   []LabelRule{{Match: MatchRegex, Pattern: "bug(", Type: Bugfix}},
+			wantErr: "label rule 0",
+		},
+		{
+			name:    "empty pattern",
+			types:   types,
+			rules:   []LabelRule{{Match: MatchExact, Type: Bugfix}},
+			wantErr: "pattern must not be empty",
+		},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(tt.types, tt.rules)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewRegistry() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package branch
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Policy is an organisation rule that branch names must match.
+type Policy struct {
+	Pattern *regexp.Regexp
+	// Description explains the rule to users when a name does not match.
+	Description string
+}
+
+// ValidateRefName checks that name is a valid branch name, following the
+// rules of `git check-ref-format --branch`.
+func ValidateRefName(name string) error {
+	invalid := func(format string, a ...any) error {
+		return fmt.Errorf("invalid branch name %q: %s", name, fmt.Sprintf(format, a...))
+	}
+
+	switch {
+	case name == "":
+		return fmt.Errorf("branch name must not be empty")
+	case name == "@":
+		return invalid("cannot be the single character \"@\"")
+	case name == "HEAD":
+		return invalid("\"HEAD\" is reserved")
+	case strings.HasPrefix(name, "-"):
+		return invalid("cannot start with \"-\"")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/"):
+		return invalid("cannot start or end with \"/\"")
+	case strings.HasSuffix(name, "."):
+		return invalid("cannot end with \".\"")
+	case strings.Contains(name, "//"):
+		return invalid("cannot contain consecutive slashes \"//\"")
+	case strings.Contains(name, ".."):
+		return invalid("cannot contain \"..\"")
+	case strings.Contains(name, "@{"):
+		return invalid("cannot contain \"@{\"")
+	}
+
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return invalid("cannot contain control characters")
+		case r == ' ':
+			return invalid("cannot contain spaces")
+		case strings.ContainsRune(`~^:?*[\`, r):
+			return invalid("cannot contain %q", r)
+		}
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return invalid("path component %q cannot start with \".\"", component)
+		}
+		if strings.HasSuffix(component, ".lock") {
+			return invalid("path component %q cannot end with \".lock\"", component)
+		}
+	}
+	return nil
+}
+
+// Validate checks name against git's ref rules, the convention's length
+// limit and its policy.
+func (c Convention) Validate(name string) error {
+	if err := ValidateRefName(name); err != nil {
+		return err
+	}
+	if c.MaxLength > 0 && runeLen(name) > c.MaxLength {
+		return fmt.Errorf("branch name %q is %d characters long, more than the limit of %d", name, runeLen(name), c.MaxLength)
+	}
+	if c.Policy != nil && !c.Policy.Pattern.MatchString(name) {
+		reason := c.Policy.Description
+		if reason == "" {
+			reason = fmt.Sprintf("must match %s", c.Policy.Pattern)
+		}
+		return fmt.Errorf("branch name %q violates the branch policy: %s", name, reason)
+	}
+	return nil
+}
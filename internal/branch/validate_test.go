@@ -0,0 +1,98 @@
+package branch
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestValidateRefName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "feature/GH-42-add-login"},
+		{name: "octocat/2024-03-05/GH-42"},
+		{name: "release/v1.2.0"},
+		{name: "fix_typo"},
+		{name: "", wantErr: "must not be empty"},
+		{name: "@", wantErr: `single character "@"`},
+		{name: "HEAD", wantErr: "reserved"},
+		{name: "-feature", wantErr: `start with "-"`},
+		{name: "/feature", wantErr: `start or end with "/"`},
+		{name: "feature/", wantErr: `start or end with "/"`},
+		{name: "feature.", wantErr: `end with "."`},
+		{name: "feature//x", wantErr: "consecutive slashes"},
+		{name: "feature/a..b", wantErr: `contain ".."`},
+		{name: "feature@{1}", wantErr: `contain "@{"`},
+		{name: "feature/add login", wantErr: "spaces"},
+		{name: "feature\tx", wantErr: "control characters"},
+		{name: "feature~1", wantErr: "'~'"},
+		{name: "feature^", wantErr: "'^'"},
+		{name: "a:b", wantErr: "':'"},
+		{name: "what?", wantErr: "'?'"},
+		{name: "glob*", wantErr: "'*'"},
+		{name: "[x]", wantErr: "'['"},
+		{name: `back\slash`, wantErr: `'\\'`},
+		{name: "feature/.hidden", wantErr: `cannot start with "."`},
+		{name: "feature.lock/x", wantErr: `cannot end with ".lock"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRefName(tt.name)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateRefName() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateRefName() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConventionValidate(t *testing.T) {
+	policy := &Policy{
+		Pattern:     regexp.MustCompile(`^(feature|bugfix)/`),
+		Description: "only feature and bugfix branches",
+	}
+
+	tests := []struct {
+		name      string
+		maxLength int
+		policy    *Policy
+		branch    string
+		wantErr   string
+	}{
+		{name: "valid", branch: "feature/GH-42-add-login"},
+		{name: "invalid ref", branch: "feature/add login", wantErr: "spaces"},
+		{name: "within max length", maxLength: 23, branch: "feature/GH-42-add-login"},
+		{name: "over max length", maxLength: 20, branch: "feature/GH-42-add-login", wantErr: "23 characters long, more than the limit of 20"},
+		{name: "max length counts runes", maxLength: 9, branch: "docs/café"},
+		{name: "matches policy", policy: policy, branch: "bugfix/GH-1-x"},
+		{name: "violates policy", policy: policy, branch: "chore/GH-1-x", wantErr: "violates the branch policy: only feature and bugfix branches"},
+		{name: "policy without description", policy: &Policy{Pattern: policy.Pattern}, branch: "chore/x", wantErr: "must match ^(feature|bugfix)/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConvention()
+			c.MaxLength = tt.maxLength
+			c.Policy = tt.policy
+
+			err := c.Validate(tt.branch)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,616 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jesusgpo/gh-buddy/internal/branch"
+	"github.com/jesusgpo/gh-buddy/internal/git"
+	"github.com/jesusgpo/gh-buddy/internal/issue"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the name of the repository-level config file, looked up at
+// the root of the current work tree.
+const RepoFileName = ".gh-buddy.yml"
+
+// Config holds the gh-buddy settings. Values are layered with the following
+// precedence: command-line flag > environment > repository file > user file >
+// built-in default. Flags are applied by the commands themselves.
+type Config struct {
+	// Remote is the git remote branches are fetched from and pushed to.
+	Remote string `yaml:"remote"`
+	// UpstreamRemote is the remote of the parent repository when Remote is a
+	// fork. Issues and pull requests then target it, and bases are fetched
+	// from it.
+	UpstreamRemote string `yaml:"upstream_remote"`
+	// Base is the default base branch. When empty it is the repository's
+	// default branch as reported with the issue, or the remote's HEAD,
+	// falling back to FallbackBase.
+	Base string `yaml:"base"`
+	// FallbackBase is used when the remote's default branch cannot be detected.
+	FallbackBase string `yaml:"fallback_base"`
+	// Hostname overrides the GitHub host detected from the remote URL, e.g.
+	// when the remote goes through an SSH alias. API tokens are sent to this
+	// host, so the repository file cannot set it.
+	Hostname string `yaml:"hostname"`
+
+	Tracker  TrackerConfig  `yaml:"tracker"`
+	Branch   BranchConfig   `yaml:"branch"`
+	Issues   IssuesConfig   `yaml:"issues"`
+	Cache    CacheConfig    `yaml:"cache"`
+	Worktree WorktreeConfig `yaml:"worktree"`
+}
+
+// WorktreeConfig holds the settings of create-branch --worktree.
+type WorktreeConfig struct {
+	// Path is the pattern of new worktree paths. Relative paths are resolved
+	// against the main work tree. See WorktreePlaceholders.
+	Path string `yaml:"path"`
+}
+
+// WorktreePlaceholders lists the names accepted in worktree.path: the
+// directory name of the main work tree, the issue key as rendered in branch
+// names, and the branch name with "/" replaced by "-".
+var WorktreePlaceholders = []string{"repo", "key", "branch"}
+
+// IssuesConfig holds the default filters of the issue picker.
+type IssuesConfig struct {
+	// Assignee is a login, "@me", "none" or "*" for anyone.
+	Assignee string `yaml:"assignee"`
+	// Labels must all be present on listed issues.
+	Labels []string `yaml:"labels"`
+	// Milestone is a milestone title, or "none".
+	Milestone string `yaml:"milestone"`
+	// Project is "owner/number", or a number of a project of the repo owner.
+	Project string `yaml:"project"`
+	// Search is free text issues must match.
+	Search string `yaml:"search"`
+	// Limit caps the number of issues listed.
+	Limit int `yaml:"limit"`
+}
+
+// CacheConfig controls the on-disk cache of GitHub issues.
+type CacheConfig struct {
+	// TTL is how long cached issues are used without asking GitHub; after
+	// that they are revalidated. 0 always revalidates.
+	TTL time.Duration `yaml:"ttl"`
+	// Disabled turns the cache off.
+	Disabled bool `yaml:"disabled"`
+}
+
+// TrackerConfig selects where issues are tracked.
+type TrackerConfig struct {
+	// Name is "github" or the name of an external tracker with
+	// "PROJECT-123" style keys, e.g. "jira".
+	Name string `yaml:"name"`
+	// Project is the default project key for bare issue numbers.
+	Project string `yaml:"project"`
+	// URL links external issues in PR bodies; "{key}" is replaced by the key.
+	URL string `yaml:"url"`
+}
+
+// BranchConfig holds the branch naming settings.
+type BranchConfig struct {
+	// Format is the branch name template, e.g. "{type}/{prefix}-{number}-{slug}".
+	Format string `yaml:"format"`
+	// LegacyFormats are older formats still recognised when parsing existing
+	// branch names.
+	LegacyFormats []string `yaml:"legacy_formats"`
+	Prefix        string   `yaml:"prefix"`
+	SlugMaxLength int      `yaml:"slug_max_length"`
+	// MaxLength caps the whole branch name; 0 means no limit.
+	MaxLength   int             `yaml:"max_length"`
+	Stopwords   StopwordsConfig `yaml:"stopwords"`
+	DefaultType string          `yaml:"default_type"`
+	Types       []TypeConfig    `yaml:"types"`
+	// Policy is an optional rule every branch name must follow.
+	Policy PolicyConfig `yaml:"policy"`
+	// OnConflict decides what happens in non-interactive mode when the branch
+	// already exists: "checkout", "suffix" or "abort".
+	OnConflict string `yaml:"on_conflict"`
+	// OnUncommitted decides what happens to uncommitted changes when a
+	// branch is created: "carry", "reapply", "stash" or "abort". It is the
+	// preselected answer in interactive mode.
+	OnUncommitted string `yaml:"on_uncommitted"`
+	// LabelRules are tried in order; the first rule matching any issue label
+	// selects the branch type. When nil, the built-in rules for the
+	// configured types are used.
+	LabelRules []LabelRuleConfig `yaml:"label_rules"`
+}
+
+// PolicyConfig is an organisation rule for branch names.
+type PolicyConfig struct {
+	// Pattern is a regular expression branch names must match.
+	Pattern string `yaml:"pattern"`
+	// Description explains the rule when a name does not match.
+	Description string `yaml:"description"`
+}
+
+// StopwordsConfig selects the words dropped from titles before slugifying.
+type StopwordsConfig struct {
+	// Languages lists the language codes whose stopwords are removed. Empty
+	// disables stopword removal.
+	Languages []string `yaml:"languages"`
+	// Words overrides the built-in list for a language.
+	Words map[string][]string `yaml:"words"`
+}
+
+// TypeConfig describes a branch type and the alternative names accepted for it.
+type TypeConfig struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+}
+
+// LabelRuleConfig maps labels matching a pattern to a branch type.
+type LabelRuleConfig struct {
+	// Match is one of "exact", "glob" or "regex".
+	Match   string `yaml:"match"`
+	Pattern string `yaml:"pattern"`
+	Type    string `yaml:"type"`
+}
+
+// Values accepted by branch.on_conflict.
+const (
+	OnConflictCheckout = "checkout"
+	OnConflictSuffix   = "suffix"
+	OnConflictAbort    = "abort"
+)
+
+// Values accepted by branch.on_uncommitted.
+const (
+	// OnUncommittedCarry leaves the changes in the work tree.
+	OnUncommittedCarry = "carry"
+	// OnUncommittedReapply stashes the changes and re-applies them on the
+	// new branch.
+	OnUncommittedReapply = "reapply"
+	// OnUncommittedStash stashes the changes and leaves them stashed.
+	OnUncommittedStash = "stash"
+	OnUncommittedAbort = "abort"
+)
+
+// ValidationError reports an invalid value in a config file.
+type ValidationError struct {
+	File string
+	Key  string
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config %s: %s: %s", e.File, e.Key, e.Msg)
+}
+
+// DefaultRemote is the remote used unless another one is configured.
+const DefaultRemote = "origin"
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	conv := branch.DefaultConvention()
+	types := make([]TypeConfig, 0, len(conv.Types.Types()))
+	for _, t := range conv.Types.Types() {
+		types = append(types, TypeConfig{Name: string(t.Name), Aliases: t.Aliases})
+	}
+	return &Config{
+		Remote:         DefaultRemote,
+		UpstreamRemote: "upstream",
+		FallbackBase:   "main",
+		Tracker: TrackerConfig{
+			Name: string(issue.GitHub),
+		},
+		Branch: BranchConfig{
+			Format:        conv.Format.String(),
+			LegacyFormats: append([]string(nil), branch.DefaultLegacyFormats...),
+			Prefix:        conv.Prefix,
+			SlugMaxLength: conv.SlugMaxLength,
+			DefaultType:   string(conv.DefaultType),
+			OnConflict:    OnConflictAbort,
+			OnUncommitted: OnUncommittedReapply,
+			Types:         types,
+		},
+		Issues: IssuesConfig{
+			Assignee: "@me",
+			Limit:    100,
+		},
+		Cache: CacheConfig{
+			TTL: 5 * time.Minute,
+		},
+		Worktree: WorktreeConfig{
+			Path: "../{repo}-{key}",
+		},
+	}
+}
+
+// UserFile returns the path of the user-level config file.
+func UserFile() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gh-buddy", "config.yml"), nil
+}
+
+// RepoFile returns the path of the repository-level config file, or an empty
+// string when not inside a git work tree.
+func RepoFile() string {
+	root, err := git.TopLevel()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(root, RepoFileName)
+}
+
+// Load builds the effective configuration from the built-in defaults, the
+// user file, the repository file and the environment, in that order.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	userFile, err := UserFile()
+	if err == nil {
+		if err := cfg.mergeFile(userFile, true); err != nil {
+			return nil, err
+		}
+	}
+	if repoFile := RepoFile(); repoFile != "" {
+		if err := cfg.mergeFile(repoFile, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.mergeEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateRefs(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// mergeFile decodes the file on top of the current values. Keys absent from
+// the file keep their previous value; lists replace the previous list. Files
+// that are not trusted, such as the one committed in the repository, may not
+// set hostname: it decides where API tokens are sent.
+func (c *Config) mergeFile(path string, trusted bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if !trusted {
+		var host struct {
+			Hostname *string `yaml:"hostname"`
+		}
+		if err := yaml.Unmarshal(data, &host); err == nil && host.Hostname != nil {
+			return &ValidationError{File: path, Key: "hostname",
+				Msg: "can only be set in the user config file, with $GH_BUDDY_HOSTNAME or with --hostname"}
+		}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return c.validate(path)
+}
+
+// envVars maps environment variables to the settings they override.
+var envVars = []struct {
+	name string
+	key  string
+	set  func(c *Config, v string) error
+}{
+	{"GH_BUDDY_REMOTE", "remote", func(c *Config, v string) error { c.Remote = v; return nil }},
+	{"GH_BUDDY_UPSTREAM_REMOTE", "upstream_remote", func(c *Config, v string) error { c.UpstreamRemote = v; return nil }},
+	{"GH_BUDDY_BASE", "base", func(c *Config, v string) error { c.Base = v; return nil }},
+	{"GH_BUDDY_FALLBACK_BASE", "fallback_base", func(c *Config, v string) error { c.FallbackBase = v; return nil }},
+	{"GH_BUDDY_HOSTNAME", "hostname", func(c *Config, v string) error { c.Hostname = v; return nil }},
+	{"GH_BUDDY_TRACKER", "tracker.name", func(c *Config, v string) error { c.Tracker.Name = v; return nil }},
+	{"GH_BUDDY_TRACKER_PROJECT", "tracker.project", func(c *Config, v string) error { c.Tracker.Project = v; return nil }},
+	{"GH_BUDDY_BRANCH_FORMAT", "branch.format", func(c *Config, v string) error { c.Branch.Format = v; return nil }},
+	{"GH_BUDDY_BRANCH_PREFIX", "branch.prefix", func(c *Config, v string) error { c.Branch.Prefix = v; return nil }},
+	{"GH_BUDDY_BRANCH_TYPE", "branch.default_type", func(c *Config, v string) error { c.Branch.DefaultType = v; return nil }},
+	{"GH_BUDDY_ON_CONFLICT", "branch.on_conflict", func(c *Config, v string) error { c.Branch.OnConflict = v; return nil }},
+	{"GH_BUDDY_ON_UNCOMMITTED", "branch.on_uncommitted", func(c *Config, v string) error { c.Branch.OnUncommitted = v; return nil }},
+	{"GH_BUDDY_SLUG_MAX_LENGTH", "branch.slug_max_length", intSetter(func(c *Config) *int { return &c.Branch.SlugMaxLength })},
+	{"GH_BUDDY_BRANCH_MAX_LENGTH", "branch.max_length", intSetter(func(c *Config) *int { return &c.Branch.MaxLength })},
+	{"GH_BUDDY_ISSUE_ASSIGNEE", "issues.assignee", func(c *Config, v string) error { c.Issues.Assignee = v; return nil }},
+	{"GH_BUDDY_ISSUE_MILESTONE", "issues.milestone", func(c *Config, v string) error { c.Issues.Milestone = v; return nil }},
+	{"GH_BUDDY_ISSUE_PROJECT", "issues.project", func(c *Config, v string) error { c.Issues.Project = v; return nil }},
+	{"GH_BUDDY_ISSUE_LIMIT", "issues.limit", intSetter(func(c *Config) *int { return &c.Issues.Limit })},
+	{"GH_BUDDY_WORKTREE_PATH", "worktree.path", func(c *Config, v string) error { c.Worktree.Path = v; return nil }},
+	{"GH_BUDDY_CACHE_TTL", "cache.ttl", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 10m")
+		}
+		c.Cache.TTL = d
+		return nil
+	}},
+}
+
+func intSetter(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func (c *Config) mergeEnv() error {
+	for _, ev := range envVars {
+		v, ok := os.LookupEnv(ev.name)
+		if !ok || v == "" {
+			continue
+		}
+		if err := ev.set(c, v); err != nil {
+			return &ValidationError{File: "$" + ev.name, Key: ev.key, Msg: err.Error()}
+		}
+	}
+	return c.validate("environment")
+}
+
+// projectRegex matches a project reference, "owner/number" or "number".
+var projectRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*/)?[0-9]+$`)
+
+// worktreePlaceholderRegex matches a placeholder in worktree.path.
+var worktreePlaceholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validate checks the merged values, attributing errors to source.
+func (c *Config) validate(source string) error {
+	invalid := func(key, format string, a ...any) error {
+		return &ValidationError{File: source, Key: key, Msg: fmt.Sprintf(format, a...)}
+	}
+
+	if c.Remote == "" {
+		return invalid("remote", "must not be empty")
+	}
+	if c.FallbackBase == "" {
+		return invalid("fallback_base", "must not be empty")
+	}
+	if c.Tracker.Name == "" {
+		return invalid("tracker.name", "must not be empty")
+	}
+	if c.Tracker.Project != "" && !issue.ValidProject(c.Tracker.Project) {
+		return invalid("tracker.project", "%q is not a valid project key", c.Tracker.Project)
+	}
+	if c.Tracker.URL != "" && !strings.Contains(c.Tracker.URL, "{key}") {
+		return invalid("tracker.url", "must contain {key}")
+	}
+	format, err := branch.ParseTemplate(c.Branch.Format)
+	if err != nil {
+		return invalid("branch.format", "%v", err)
+	}
+	// The prefix ends up in branch names, so it must follow git's rules too
+	if err := branch.ValidateRefName(format.SampleName(c.Branch.Prefix)); err != nil {
+		return invalid("branch.prefix", "%q does not give valid branch names: %v", c.Branch.Prefix, err)
+	}
+	for i, f := range c.Branch.LegacyFormats {
+		if _, err := branch.ParseTemplate(f); err != nil {
+			return invalid(fmt.Sprintf("branch.legacy_formats[%d]", i), "%v", err)
+		}
+	}
+	if c.Branch.SlugMaxLength < 0 {
+		return invalid("branch.slug_max_length", "must not be negative, got %d", c.Branch.SlugMaxLength)
+	}
+	if c.Branch.MaxLength < 0 {
+		return invalid("branch.max_length", "must not be negative, got %d", c.Branch.MaxLength)
+	}
+	for i, lang := range c.Branch.Stopwords.Languages {
+		_, builtin := branch.DefaultStopwords[lang]
+		_, custom := c.Branch.Stopwords.Words[lang]
+		if !builtin && !custom {
+			return invalid(fmt.Sprintf("branch.stopwords.languages[%d]", i),
+				"no stopwords for %q; add them under branch.stopwords.words.%s", lang, lang)
+		}
+	}
+	if c.Branch.Policy.Pattern != "" {
+		if _, err := regexp.Compile(c.Branch.Policy.Pattern); err != nil {
+			return invalid("branch.policy.pattern", "%v", err)
+		}
+	}
+	switch c.Branch.OnConflict {
+	case OnConflictCheckout, OnConflictSuffix, OnConflictAbort:
+	default:
+		return invalid("branch.on_conflict", "must be one of %s, %s or %s, got %q",
+			OnConflictCheckout, OnConflictSuffix, OnConflictAbort, c.Branch.OnConflict)
+	}
+	switch c.Branch.OnUncommitted {
+	case OnUncommittedCarry, OnUncommittedReapply, OnUncommittedStash, OnUncommittedAbort:
+	default:
+		return invalid("branch.on_uncommitted", "must be one of %s, %s, %s or %s, got %q",
+			OnUncommittedCarry, OnUncommittedReapply, OnUncommittedStash, OnUncommittedAbort, c.Branch.OnUncommitted)
+	}
+	if len(c.Branch.Types) == 0 {
+		return invalid("branch.types", "at least one type is required")
+	}
+	if c.Issues.Limit < 1 || c.Issues.Limit > 1000 {
+		return invalid("issues.limit", "must be between 1 and 1000, got %d", c.Issues.Limit)
+	}
+	if c.Issues.Project != "" && !projectRegex.MatchString(c.Issues.Project) {
+		return invalid("issues.project", "must be \"owner/number\" or a number, got %q", c.Issues.Project)
+	}
+	if c.Cache.TTL < 0 {
+		return invalid("cache.ttl", "must not be negative, got %s", c.Cache.TTL)
+	}
+	if c.Worktree.Path == "" {
+		return invalid("worktree.path", "must not be empty")
+	}
+	for _, m := range worktreePlaceholderRegex.FindAllStringSubmatch(c.Worktree.Path, -1) {
+		if !slices.Contains(WorktreePlaceholders, m[1]) {
+			return invalid("worktree.path", "unknown placeholder {%s}; valid placeholders: %s", m[1], strings.Join(WorktreePlaceholders, ", "))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, t := range c.Branch.Types {
+		key := fmt.Sprintf("branch.types[%d].name", i)
+		if t.Name == "" {
+			return invalid(key, "must not be empty")
+		}
+		for j, name := range append([]string{t.Name}, t.Aliases...) {
+			if j > 0 {
+				key = fmt.Sprintf("branch.types[%d].aliases[%d]", i, j-1)
+			}
+			if seen[strings.ToLower(name)] {
+				return invalid(key, "%q is already declared by another type", name)
+			}
+			seen[strings.ToLower(name)] = true
+		}
+	}
+
+	for i, r := range c.Branch.LabelRules {
+		rule := r.labelRule()
+		if err := rule.Compile(); err != nil {
+			return invalid(fmt.Sprintf("branch.label_rules[%d]", i), "%v", err)
+		}
+	}
+	return nil
+}
+
+func (r LabelRuleConfig) labelRule() branch.LabelRule {
+	return branch.LabelRule{
+		Match:   branch.MatchKind(r.Match),
+		Pattern: r.Pattern,
+		Type:    branch.IssueType(r.Type),
+	}
+}
+
+// validateRefs checks settings that refer to other settings. It runs once on
+// the merged config so that layers can define and reference values
+// independently.
+func (c *Config) validateRefs() error {
+	invalid := func(key, format string, a ...any) error {
+		return &ValidationError{File: "merged config", Key: key, Msg: fmt.Sprintf(format, a...)}
+	}
+
+	registry, err := branch.NewRegistry(c.typeDefs(), nil)
+	if err != nil {
+		return invalid("branch.types", "%v", err)
+	}
+	if !registry.ValidIssueType(c.Branch.DefaultType) {
+		return invalid("branch.default_type", "%q is not one of the configured types", c.Branch.DefaultType)
+	}
+	for i, r := range c.Branch.LabelRules {
+		if !registry.ValidIssueType(r.Type) {
+			return invalid(fmt.Sprintf("branch.label_rules[%d].type", i), "%q is not one of the configured types", r.Type)
+		}
+	}
+	return nil
+}
+
+func (c *Config) typeDefs() []branch.TypeDef {
+	types := make([]branch.TypeDef, len(c.Branch.Types))
+	for i, t := range c.Branch.Types {
+		types[i] = branch.TypeDef{Name: branch.IssueType(t.Name), Aliases: t.Aliases}
+	}
+	return types
+}
+
+// labelRules returns the configured label rules, or the built-in rules whose
+// type is among the configured types when none are configured.
+func (c *Config) labelRules() ([]branch.LabelRule, error) {
+	if c.Branch.LabelRules != nil {
+		rules := make([]branch.LabelRule, len(c.Branch.LabelRules))
+		for i, r := range c.Branch.LabelRules {
+			rules[i] = r.labelRule()
+		}
+		return rules, nil
+	}
+
+	registry, err := branch.NewRegistry(c.typeDefs(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var rules []branch.LabelRule
+	for _, r := range branch.DefaultLabelRules() {
+		if registry.ValidIssueType(string(r.Type)) {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// IssueScheme returns the issue key scheme described by the config.
+func (c *Config) IssueScheme() issue.Scheme {
+	return issue.Scheme{
+		Tracker: issue.Tracker(c.Tracker.Name),
+		Project: c.Tracker.Project,
+		URL:     c.Tracker.URL,
+	}
+}
+
+// Convention returns the branch naming convention described by the config.
+func (c *Config) Convention() (branch.Convention, error) {
+	rules, err := c.labelRules()
+	if err != nil {
+		return branch.Convention{}, fmt.Errorf("invalid branch types: %w", err)
+	}
+	registry, err := branch.NewRegistry(c.typeDefs(), rules)
+	if err != nil {
+		return branch.Convention{}, fmt.Errorf("invalid branch types: %w", err)
+	}
+
+	format, err := branch.ParseTemplate(c.Branch.Format)
+	if err != nil {
+		return branch.Convention{}, fmt.Errorf("invalid branch format: %w", err)
+	}
+
+	legacy := make([]*branch.Template, len(c.Branch.LegacyFormats))
+	for i, f := range c.Branch.LegacyFormats {
+		if legacy[i], err = branch.ParseTemplate(f); err != nil {
+			return branch.Convention{}, fmt.Errorf("invalid legacy branch format: %w", err)
+		}
+	}
+
+	stopwords := make(map[string]bool)
+	for _, lang := range c.Branch.Stopwords.Languages {
+		words, ok := c.Branch.Stopwords.Words[lang]
+		if !ok {
+			words = branch.DefaultStopwords[lang]
+		}
+		for _, w := range words {
+			stopwords[strings.ToLower(w)] = true
+		}
+	}
+
+	var policy *branch.Policy
+	if c.Branch.Policy.Pattern != "" {
+		pattern, err := regexp.Compile(c.Branch.Policy.Pattern)
+		if err != nil {
+			return branch.Convention{}, fmt.Errorf("invalid branch policy: %w", err)
+		}
+		policy = &branch.Policy{Pattern: pattern, Description: c.Branch.Policy.Description}
+	}
+
+	defaultType, _ := registry.Resolve(c.Branch.DefaultType)
+	return branch.Convention{
+		Format:        format,
+		LegacyFormats: legacy,
+		Prefix:        c.Branch.Prefix,
+		Keys:          c.IssueScheme(),
+		SlugMaxLength: c.Branch.SlugMaxLength,
+		MaxLength:     c.Branch.MaxLength,
+		Stopwords:     stopwords,
+		DefaultType:   defaultType,
+		Types:         registry,
+		Policy:        policy,
+	}, nil
+}
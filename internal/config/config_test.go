@@ -0,0 +1,315 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesusgpo/gh-buddy/internal/branch"
+)
+
+// setupLoad isolates Load from the caller's environment: it clears the
+// GH_BUDDY_* variables, points the user config at a temp dir and runs the
+// test inside a new git work tree. It returns the user and repository file
+// paths.
+func setupLoad(t *testing.T) (userFile, repoFile string) {
+	t.Helper()
+	for _, ev := range envVars {
+		t.Setenv(ev.name, "")
+	}
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	t.Chdir(repo)
+
+	userFile = filepath.Join(home, "gh-buddy", "config.yml")
+	if err := os.MkdirAll(filepath.Dir(userFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Resolve symlinks in the temp dir, as git reports the real path.
+	root, err := filepath.EvalSymlinks(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userFile, filepath.Join(root, RepoFileName)
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setupLoad(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := Default()
+	if cfg.Remote != want.Remote || cfg.FallbackBase != want.FallbackBase || cfg.Branch.Prefix != want.Branch.Prefix || cfg.Cache.TTL != want.Cache.TTL {
+		t.Errorf("Load() = %+v, want the defaults %+v", cfg, want)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	userFile, repoFile := setupLoad(t)
+
+	writeFile(t, userFile, `
+remote: fork
+hostname: ghe.example.com
+base: develop
+branch:
+  format: "{user}/{number}-{slug}"
+  prefix: USR
+  slug_max_length: 40
+  stopwords:
+    languages: [en]
+cache:
+  ttl: 1h
+`)
+	writeFile(t, repoFile, `
+base: trunk
+branch:
+  prefix: REPO
+  types:
+    - name: feature
+    - name: bugfix
+      aliases: [fix]
+`)
+	t.Setenv("GH_BUDDY_BRANCH_PREFIX", "ENV")
+	t.Setenv("GH_BUDDY_BRANCH_MAX_LENGTH", "72")
+	t.Setenv("GH_BUDDY_CACHE_TTL", "2m")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		got  any
+		want any
+	}{
+		{"remote (user file)", cfg.Remote, "fork"},
+		{"base (repo file over user file)", cfg.Base, "trunk"},
+		{"hostname (user file)", cfg.Hostname, "ghe.example.com"},
+		{"branch.format (user file)", cfg.Branch.Format, "{user}/{number}-{slug}"},
+		{"branch.prefix (environment over files)", cfg.Branch.Prefix, "ENV"},
+		{"branch.slug_max_length (user file)", cfg.Branch.SlugMaxLength, 40},
+		{"branch.stopwords (user file)", strings.Join(cfg.Branch.Stopwords.Languages, ","), "en"},
+		{"branch.max_length (environment)", cfg.Branch.MaxLength, 72},
+		{"cache.ttl (environment over user file)", cfg.Cache.TTL, 2 * time.Minute},
+		{"branch.types (repo file replaces the list)", len(cfg.Branch.Types), 2},
+		{"fallback_base (default)", cfg.FallbackBase, "main"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		repo     string
+		env      map[string]string
+		wantFile string
+		wantKey  string
+		wantMsg  string
+	}{
+		{
+			name:     "invalid user file value",
+			user:     "branch:\n  slug_max_length: -1\n",
+			wantFile: "user",
+			wantKey:  "branch.slug_max_length",
+			wantMsg:  "must not be negative",
+		},
+		{
+			name:     "invalid conflict policy",
+			user:     "branch:\n  on_conflict: ask\n",
+			wantFile: "user",
+			wantKey:  "branch.on_conflict",
+			wantMsg:  `got "ask"`,
+		},
+		{
+			name:     "alias declared twice in repo file",
+			repo:     "branch:\n  types:\n    - name: feature\n      aliases: [feat]\n    - name: bugfix\n      aliases: [FEAT]\n",
+			wantFile: "repo",
+			wantKey:  "branch.types[1].aliases[0]",
+			wantMsg:  `"FEAT" is already declared by another type`,
+		},
+		{
+			name:     "invalid repo file format",
+			repo:     "branch:\n  format: \"{type}/{title}\"\n",
+			wantFile: "repo",
+			wantKey:  "branch.format",
+			wantMsg:  "unknown placeholder {title}",
+		},
+		{
+			name:     "prefix with a space",
+			repo:     "branch:\n  prefix: \"a b\"\n",
+			wantFile: "repo",
+			wantKey:  "branch.prefix",
+			wantMsg:  "cannot contain spaces",
+		},
+		{
+			name:     "prefix with consecutive dots",
+			env:      map[string]string{"GH_BUDDY_BRANCH_PREFIX": "x..y"},
+			wantFile: "environment",
+			wantKey:  "branch.prefix",
+			wantMsg:  `cannot contain ".."`,
+		},
+		{
+			name:     "hostname in repo file",
+			repo:     "hostname: attacker.example.com\n",
+			wantFile: "repo",
+			wantKey:  "hostname",
+			wantMsg:  "can only be set in the user config file",
+		},
+		{
+			name:     "invalid legacy format",
+			repo:     "branch:\n  legacy_formats:\n    - \"{type}/{slug}\"\n    - \"{type}\"\n",
+			wantFile: "repo",
+			wantKey:  "branch.legacy_formats[1]",
+			wantMsg:  "must contain {slug}, {number} or {key}",
+		},
+		{
+			name:     "stopwords language without words",
+			user:     "branch:\n  stopwords:\n    languages: [xx]\n",
+			wantFile: "user",
+			wantKey:  "branch.stopwords.languages[0]",
+			wantMsg:  `no stopwords for "xx"`,
+		},
+		{
+			name:     "invalid policy pattern",
+			repo:     "branch:\n  policy:\n    pattern: \"^(feature\"\n",
+			wantFile: "repo",
+			wantKey:  "branch.policy.pattern",
+			wantMsg:  "missing closing )",
+		},
+		{
+			name:     "tracker URL without key",
+			repo:     "tracker:\n  name: jira\n  project: PAY\n  url: https://jira.example.com/browse\n",
+			wantFile: "repo",
+			wantKey:  "tracker.url",
+			wantMsg:  "must contain {key}",
+		},
+		{
+			name:     "invalid environment integer",
+			env:      map[string]string{"GH_BUDDY_SLUG_MAX_LENGTH": "many"},
+			wantFile: "$GH_BUDDY_SLUG_MAX_LENGTH",
+			wantKey:  "branch.slug_max_length",
+			wantMsg:  "must be an integer",
+		},
+		{
+			name:     "environment out of range",
+			env:      map[string]string{"GH_BUDDY_SLUG_MAX_LENGTH": "-5"},
+			wantFile: "environment",
+			wantKey:  "branch.slug_max_length",
+			wantMsg:  "must not be negative",
+		},
+		{
+			name:     "invalid environment issue limit",
+			env:      map[string]string{"GH_BUDDY_ISSUE_LIMIT": "many"},
+			wantFile: "$GH_BUDDY_ISSUE_LIMIT",
+			wantKey:  "issues.limit",
+			wantMsg:  "must be an integer",
+		},
+		{
+			name:     "issue limit out of range",
+			env:      map[string]string{"GH_BUDDY_ISSUE_LIMIT": "5000"},
+			wantFile: "environment",
+			wantKey:  "issues.limit",
+			wantMsg:  "must be between 1 and 1000",
+		},
+		{
+			name:     "default type removed by another layer",
+			user:     "branch:\n  default_type: chore\n",
+			repo:     "branch:\n  types:\n    - name: feature\n",
+			wantFile: "merged config",
+			wantKey:  "branch.default_type",
+			wantMsg:  `"chore" is not one of the configured types`,
+		},
+		{
+			name:     "label rule for a removed type",
+			repo:     "branch:\n  types:\n    - name: feature\n  label_rules:\n    - match: exact\n      pattern: bug\n      type: bugfix\n",
+			wantFile: "merged config",
+			wantKey:  "branch.label_rules[0].type",
+			wantMsg:  `"bugfix" is not one of the configured types`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userFile, repoFile := setupLoad(t)
+			files := map[string]string{"user": userFile, "repo": repoFile}
+			if tt.user != "" {
+				writeFile(t, userFile, tt.user)
+			}
+			if tt.repo != "" {
+				writeFile(t, repoFile, tt.repo)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := Load()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Load() error = %v, want a *ValidationError", err)
+			}
+			wantFile := tt.wantFile
+			if f, ok := files[wantFile]; ok {
+				wantFile = f
+			}
+			if verr.File != wantFile || verr.Key != tt.wantKey || !strings.Contains(verr.Msg, tt.wantMsg) {
+				t.Errorf("Load() error = %v, want %s: %s: ...%s...", err, wantFile, tt.wantKey, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestConventionBuiltInLabelRules(t *testing.T) {
+	_, repoFile := setupLoad(t)
+	writeFile(t, repoFile, "branch:\n  types:\n    - name: feature\n    - name: chore\n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	conv, err := cfg.Convention()
+	if err != nil {
+		t.Fatalf("Convention() error = %v", err)
+	}
+	// The built-in rules for types that were left out are dropped
+	for label, want := range map[string]branch.IssueType{"enhancement": branch.Feature, "bug": ""} {
+		if got := conv.Types.Infer([]string{label}); got != want {
+			t.Errorf("Infer(%q) = %q, want %q", label, got, want)
+		}
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	_, repoFile := setupLoad(t)
+	writeFile(t, repoFile, "branch:\n  prefx: USR\n")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "field prefx not found") {
+		t.Errorf("Load() error = %v, want an unknown field error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package ghapi
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHost is the host of github.com.
+const DefaultHost = "github.com"
+
+// Token returns an API token for host. It looks at the environment variables
+// gh itself honours, then gh's hosts.yml, and finally asks `gh auth token`
+// (needed when gh keeps the token in the system keyring).
+func Token(host string) (string, error) {
+	envVars := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if !isDefaultHost(host) {
+		envVars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	for _, name := range envVars {
+		if token := os.Getenv(name); token != "" {
+			return token, nil
+		}
+	}
+
+	if token := hostsFileToken(host); token != "" {
+		return token, nil
+	}
+
+	out, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err == nil {
+		if token := strings.TrimSpace(string(out)); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("no GitHub token found for %s", host)
+}
+
+// ghConfigDir returns the directory where gh keeps its configuration.
+func ghConfigDir() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gh"), nil
+}
+
+// hostsFileToken reads the token for host from gh's hosts.yml, if stored there.
+func hostsFileToken(host string) string {
+	dir, err := ghConfigDir()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		return ""
+	}
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return ""
+	}
+	return hosts[host].OAuthToken
+}
+
+func isDefaultHost(host string) bool {
+	return host == "" || strings.EqualFold(host, DefaultHost)
+}
@@ -0,0 +1,104 @@
+package ghapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps API responses on disk so they can be revalidated with ETags
+// instead of fetched again, and served when GitHub cannot be reached.
+type Cache struct {
+	// Dir is the directory entries are stored in.
+	Dir string
+	// TTL is how long an entry is used without revalidating it.
+	TTL time.Duration
+	// Refresh revalidates entries even when they are fresh.
+	Refresh bool
+	// Warn, if set, is called when stale data is served.
+	Warn func(format string, a ...any)
+}
+
+// cacheEntry is a cached response body.
+type cacheEntry struct {
+	ETag      string          `json:"etag"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// NewCache returns a cache in the user cache directory.
+func NewCache(ttl time.Duration) (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: filepath.Join(dir, "gh-buddy"), TTL: ttl}, nil
+}
+
+// path returns the file of the entry for key, a "host/owner/repo/..." path.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(key)+".json")
+}
+
+func (c *Cache) load(key string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// store saves an entry. Failing to write the cache is not an error worth
+// reporting; the data is simply fetched again next time.
+func (c *Cache) store(key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
+
+// fresh reports whether entry can be used without revalidating it.
+func (c *Cache) fresh(entry *cacheEntry) bool {
+	return !c.Refresh && time.Since(entry.FetchedAt) < c.TTL
+}
+
+// stale decodes a cached entry into out after a failed request, warning
+// that the data may be out of date.
+func (c *Cache) stale(entry *cacheEntry, err error, out any) error {
+	c.warn("Could not reach GitHub (%v); using data cached %s ago",
+		err, time.Since(entry.FetchedAt).Round(time.Second))
+	return json.Unmarshal(entry.Body, out)
+}
+
+// warn calls Warn, if set.
+func (c *Cache) warn(format string, a ...any) {
+	if c.Warn != nil {
+		c.Warn(format, a...)
+	}
+}
+
+// isUnavailable reports whether err means GitHub could not be reached or
+// could not answer, so cached data is better than nothing.
+func isUnavailable(err error) bool {
+	var apiErr *APIError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode >= 500 || apiErr.Kind == ErrRateLimited
+	case errors.As(err, &urlErr):
+		return true
+	}
+	return false
+}
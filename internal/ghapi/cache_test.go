@@ -0,0 +1,98 @@
+package ghapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientCachedGet(t *testing.T) {
+	const key = "github.com/repos/octo/demo/issues/42"
+	cachedBody := `{"number":42,"title":"Cached title"}`
+
+	tests := []struct {
+		name string
+		// age of the cached entry; 0 means there is none
+		age     time.Duration
+		refresh bool
+		// status answered by the server; 0 means no request is expected
+		status      int
+		wantTitle   string
+		wantETag    string
+		wantWarning bool
+		wantErr     bool
+		// wantKind is the kind of the error, if any
+		wantKind error
+	}{
+		{name: "not cached", status: http.StatusOK, wantTitle: "Fresh title", wantETag: `"v2"`},
+		{name: "fresh", age: time.Minute, wantTitle: "Cached title"},
+		{name: "expired and not modified", age: time.Hour, status: http.StatusNotModified, wantTitle: "Cached title"},
+		{name: "expired and modified", age: time.Hour, status: http.StatusOK, wantTitle: "Fresh title", wantETag: `"v2"`},
+		{name: "fresh but refreshed", age: time.Minute, refresh: true, status: http.StatusNotModified, wantTitle: "Cached title"},
+		{name: "expired and unavailable", age: time.Hour, status: http.StatusServiceUnavailable, wantTitle: "Cached title", wantWarning: true},
+		{name: "not cached and unavailable", status: http.StatusServiceUnavailable, wantErr: true},
+		{name: "expired and gone", age: time.Hour, status: http.StatusNotFound, wantErr: true, wantKind: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if tt.age > 0 {
+					if got := r.Header.Get("If-None-Match"); got != `"v1"` {
+						t.Errorf("If-None-Match = %q, want the cached ETag", got)
+					}
+				}
+				if tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("ETag", `"v2"`)
+				fmt.Fprint(w, `{"number":42,"title":"Fresh title"}`)
+			})
+			warned := false
+			c.Cache = &Cache{
+				Dir:     t.TempDir(),
+				TTL:     5 * time.Minute,
+				Refresh: tt.refresh,
+				Warn:    func(string, ...any) { warned = true },
+			}
+			if tt.age > 0 {
+				c.Cache.store(key, &cacheEntry{ETag: `"v1"`, FetchedAt: time.Now().Add(-tt.age), Body: []byte(cachedBody)})
+			}
+
+			issue, err := c.GetIssue("octo/demo", 42)
+			switch {
+			case tt.wantErr:
+				if err == nil || (tt.wantKind != nil && !errors.Is(err, tt.wantKind)) {
+					t.Fatalf("GetIssue() error = %v, want an error of kind %v", err, tt.wantKind)
+				}
+				return
+			case err != nil:
+				t.Fatalf("GetIssue() error = %v", err)
+			}
+			if issue.Title != tt.wantTitle {
+				t.Errorf("GetIssue() title = %q, want %q", issue.Title, tt.wantTitle)
+			}
+			if (tt.status == 0) != (requests == 0) {
+				t.Errorf("%d requests sent, want them only when the entry is not fresh", requests)
+			}
+			if warned != tt.wantWarning {
+				t.Errorf("warned = %v, want %v", warned, tt.wantWarning)
+			}
+
+			entry, ok := c.Cache.load(key)
+			switch {
+			case !ok:
+				t.Fatal("no cache entry after GetIssue()")
+			case tt.wantETag != "" && entry.ETag != tt.wantETag:
+				t.Errorf("cached ETag = %q, want %q", entry.ETag, tt.wantETag)
+			case tt.status == http.StatusNotModified && time.Since(entry.FetchedAt) > time.Minute:
+				t.Errorf("cached entry not renewed after 304: fetched %s ago", time.Since(entry.FetchedAt))
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package ghapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// IssueContext is an issue together with everything the commands need to
+// know around it, fetched in a single GraphQL query.
+type IssueContext struct {
+	Issue
+	// Repo is the "owner/repo" the issue belongs to.
+	Repo      string
+	Assignees []string
+	// Projects are the titles of the projects the issue is in. It is nil
+	// when the token cannot read projects.
+	Projects []string
+	// LinkedBranches are the branches created for the issue.
+	LinkedBranches []string
+	// LinkedPRs are the open pull requests that will close the issue.
+	LinkedPRs []PullRequest
+	Parent    *IssueRef
+	SubIssues []IssueRef
+	// DefaultBranch is the default branch of the issue's repository.
+	DefaultBranch string
+	// Viewer is the login of the authenticated user.
+	Viewer string
+}
+
+// IssueRef is a short reference to a related issue.
+type IssueRef struct {
+	Repo   string
+	Number int
+	Title  string
+	State  string
+}
+
+// String returns the reference as "owner/repo#123".
+func (r IssueRef) String() string {
+	return fmt.Sprintf("%s#%d", r.Repo, r.Number)
+}
+
+// issueContextQuery returns the query for IssueContext. Project items need
+// the read:project scope, so they can be left out.
+func issueContextQuery(withProjects bool) string {
+	projects := ""
+	if withProjects {
+		projects = "\n        projectItems(first: 20) { nodes { project { title } } }"
+	}
+	return `query($owner: String!, $name: String!, $number: Int!) {
+  viewer { login }
+  repository(owner: $owner, name: $name) {
+    nameWithOwner
+    defaultBranchRef { name }
+    issueOrPullRequest(number: $number) {
+      __typename
+      ... on Issue {
+        number
+        title
+        body
+        state
+        url
+        labels(first: 100) { nodes { name } }
+        milestone { number title }
+        assignees(first: 20) { nodes { login } }` + projects + `
+        linkedBranches(first: 20) { nodes { ref { name } } }
+        closedByPullRequestsReferences(first: 20) { nodes { number title url state } }
+        parent { number title state repository { nameWithOwner } }
+        subIssues(first: 50) { nodes { number title state repository { nameWithOwner } } }
+      }
+    }
+  }
+}`
+}
+
+// issueLinksQuery fetches the part of issueContextQuery that changes from one
+// run to the next: the branches and pull requests linked to the issue.
+const issueLinksQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    issueOrPullRequest(number: $number) {
+      __typename
+      ... on Issue {
+        linkedBranches(first: 20) { nodes { ref { name } } }
+        closedByPullRequestsReferences(first: 20) { nodes { number title url state } }
+      }
+    }
+  }
+}`
+
+// issueContextData is the "data" of an issueContextQuery response.
+type issueContextData struct {
+	Viewer struct {
+		Login string `json:"login"`
+	} `json:"viewer"`
+	Repository struct {
+		NameWithOwner    string `json:"nameWithOwner"`
+		DefaultBranchRef *struct {
+			Name string `json:"name"`
+		} `json:"defaultBranchRef"`
+		IssueOrPullRequest *struct {
+			Typename  string       `json:"__typename"`
+			Number    int          `json:"number"`
+			Title     string       `json:"title"`
+			Body      string       `json:"body"`
+			State     string       `json:"state"`
+			URL       string       `json:"url"`
+			Labels    nodes[Label] `json:"labels"`
+			Milestone *Milestone   `json:"milestone"`
+			Assignees nodes[struct {
+				Login string `json:"login"`
+			}] `json:"assignees"`
+			ProjectItems *nodes[struct {
+				Project struct {
+					Title string `json:"title"`
+				} `json:"project"`
+			}] `json:"projectItems"`
+			LinkedBranches nodes[struct {
+				Ref *struct {
+					Name string `json:"name"`
+				} `json:"ref"`
+			}] `json:"linkedBranches"`
+			ClosedByPullRequestsReferences nodes[struct {
+				Number int    `json:"number"`
+				Title  string `json:"title"`
+				URL    string `json:"url"`
+				State  string `json:"state"`
+			}] `json:"closedByPullRequestsReferences"`
+			Parent    *issueRefData       `json:"parent"`
+			SubIssues nodes[issueRefData] `json:"subIssues"`
+		} `json:"issueOrPullRequest"`
+	} `json:"repository"`
+}
+
+type nodes[T any] struct {
+	Nodes []T `json:"nodes"`
+}
+
+type issueRefData struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+}
+
+func (d issueRefData) ref() IssueRef {
+	return IssueRef{Repo: d.Repository.NameWithOwner, Number: d.Number, Title: d.Title, State: d.State}
+}
+
+// setLinks replaces the linked branches and pull requests with those of an
+// issueLinksQuery response. An issue that is gone is reported as not found.
+func (d *issueContextData) setLinks(links *issueContextData) {
+	i, current := d.Repository.IssueOrPullRequest, links.Repository.IssueOrPullRequest
+	switch {
+	case i == nil || i.Typename != "Issue":
+	case current == nil || current.Typename != "Issue":
+		d.Repository.IssueOrPullRequest = nil
+	default:
+		i.LinkedBranches = current.LinkedBranches
+		i.ClosedByPullRequestsReferences = current.ClosedByPullRequestsReferences
+	}
+}
+
+// issueContext converts a response into an IssueContext.
+func (d *issueContextData) issueContext(number int) (*IssueContext, error) {
+	i := d.Repository.IssueOrPullRequest
+	switch {
+	case i == nil:
+		return nil, &APIError{Kind: ErrNotFound, Message: fmt.Sprintf("issue #%d not found", number)}
+	case i.Typename == "PullRequest":
+		return nil, fmt.Errorf("issue #%d %w", number, ErrPullRequest)
+	}
+
+	ctx := &IssueContext{
+		Issue: Issue{
+			Number:    i.Number,
+			Title:     i.Title,
+			Body:      i.Body,
+			Labels:    i.Labels.Nodes,
+			Milestone: i.Milestone,
+			State:     strings.ToLower(i.State),
+			URL:       i.URL,
+		},
+		Repo:   d.Repository.NameWithOwner,
+		Viewer: d.Viewer.Login,
+	}
+	if d.Repository.DefaultBranchRef != nil {
+		ctx.DefaultBranch = d.Repository.DefaultBranchRef.Name
+	}
+	for _, a := range i.Assignees.Nodes {
+		ctx.Assignees = append(ctx.Assignees, a.Login)
+	}
+	if i.ProjectItems != nil {
+		ctx.Projects = []string{}
+		for _, p := range i.ProjectItems.Nodes {
+			ctx.Projects = append(ctx.Projects, p.Project.Title)
+		}
+	}
+	for _, b := range i.LinkedBranches.Nodes {
+		if b.Ref != nil {
+			ctx.LinkedBranches = append(ctx.LinkedBranches, b.Ref.Name)
+		}
+	}
+	for _, pr := range i.ClosedByPullRequestsReferences.Nodes {
+		if pr.State == "OPEN" {
+			ctx.LinkedPRs = append(ctx.LinkedPRs, PullRequest{Number: pr.Number, Title: pr.Title, URL: pr.URL})
+		}
+	}
+	if i.Parent != nil {
+		parent := i.Parent.ref()
+		ctx.Parent = &parent
+	}
+	for _, sub := range i.SubIssues.Nodes {
+		ctx.SubIssues = append(ctx.SubIssues, sub.ref())
+	}
+	return ctx, nil
+}
+
+// isProjectScopeError reports whether err is caused by the token lacking
+// the scope to read projects.
+func isProjectScopeError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind != ErrUnauthorized {
+		return false
+	}
+	for _, s := range apiErr.Scopes {
+		if strings.Contains(s, "project") {
+			return true
+		}
+	}
+	return strings.Contains(apiErr.Message, "project")
+}
+
+// isSchemaError reports whether err is caused by the server not knowing a
+// field of the query, as on GitHub Enterprise Server versions without
+// sub-issues.
+func isSchemaError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "doesn't exist on type")
+}
+
+// restIssueContext fetches the issue alone, for servers that cannot answer
+// issueContextQuery. Everything around the issue is left empty.
+func restIssueContext(c Client, repo string, number int) (*IssueContext, error) {
+	issue, err := c.GetIssue(repo, number)
+	if err != nil {
+		return nil, err
+	}
+	return &IssueContext{Issue: *issue, Repo: repo}, nil
+}
+
+// decodeGraphQL decodes a raw GraphQL response, as printed by `gh api
+// graphql`, into out.
+func decodeGraphQL(data []byte, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return newGraphQLError(resp.Errors)
+	}
+	return json.Unmarshal(resp.Data, out)
+}
@@ -0,0 +1,224 @@
+package ghapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of API failures, matched with errors.Is. Client methods return an
+// *APIError carrying the details.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrSSO          = errors.New("SAML SSO authorization required")
+	ErrValidation   = errors.New("validation failed")
+	// ErrPullRequest is returned by GetIssue when the number belongs to a
+	// pull request.
+	ErrPullRequest = errors.New("is a pull request, not an issue")
+)
+
+// APIError is a failed GitHub API request.
+type APIError struct {
+	// Kind is one of the Err* values above, or nil for other failures.
+	Kind       error
+	StatusCode int
+	Message    string
+	// Reset is when the rate limit resets, for ErrRateLimited, or when a
+	// server error may be retried.
+	Reset time.Time
+	// Fields describes the rejected fields, for ErrValidation.
+	Fields []FieldError
+	// Scopes lists the OAuth scopes the request needs but the token lacks.
+	Scopes []string
+	// SSOURL is where to authorize the token, for ErrSSO.
+	SSOURL string
+}
+
+// FieldError is a field rejected by the API.
+type FieldError struct {
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s %s", e.Field, e.Code)
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" && e.Kind != nil {
+		msg = e.Kind.Error()
+	}
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (HTTP %d)", msg, e.StatusCode)
+	}
+	if len(e.Fields) > 0 {
+		details := make([]string, len(e.Fields))
+		for i, f := range e.Fields {
+			details[i] = f.String()
+		}
+		msg += ": " + strings.Join(details, "; ")
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+var ssoURLRegex = regexp.MustCompile(`url=(\S+)`)
+
+// newAPIError decodes a failed REST response. header may be nil when it is
+// not available, e.g. when the request went through gh.
+func newAPIError(status int, header http.Header, body []byte) *APIError {
+	var payload struct {
+		Message string       `json:"message"`
+		Errors  []FieldError `json:"errors"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	e := &APIError{StatusCode: status, Message: payload.Message, Fields: payload.Errors}
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+
+	switch {
+	case status == http.StatusNotFound || status == http.StatusGone:
+		e.Kind = ErrNotFound
+	case status == http.StatusUnauthorized:
+		e.Kind = ErrUnauthorized
+	case status == http.StatusUnprocessableEntity:
+		e.Kind = ErrValidation
+	case status == http.StatusForbidden || status == http.StatusTooManyRequests:
+		if sso := header.Get("X-GitHub-SSO"); sso != "" {
+			e.Kind = ErrSSO
+			if m := ssoURLRegex.FindStringSubmatch(sso); m != nil {
+				e.SSOURL = m[1]
+			}
+		} else if reset, ok := rateLimitReset(header); ok || isRateLimitMessage(e.Message) {
+			e.Kind = ErrRateLimited
+			e.Reset = reset
+		} else {
+			e.Kind = ErrUnauthorized
+			e.Scopes = missingScopes(header)
+		}
+	default:
+		e.Kind = classifyMessage(e.Message)
+	}
+	if status >= 500 && e.Reset.IsZero() {
+		// Unavailable servers may say when to come back
+		e.Reset, _ = rateLimitReset(header)
+	}
+	return e
+}
+
+// rateLimitReset returns when a rate-limited request may be retried, from
+// the Retry-After or X-RateLimit-Reset headers.
+func rateLimitReset(header http.Header) (time.Time, bool) {
+	if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(secs) * time.Second), true
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if epoch, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(epoch, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func isRateLimitMessage(msg string) bool {
+	return strings.Contains(strings.ToLower(msg), "rate limit")
+}
+
+// missingScopes returns the scopes accepted for the request that the token
+// does not have. It returns nil when the token has any of them.
+func missingScopes(header http.Header) []string {
+	accepted := splitScopes(header.Get("X-Accepted-OAuth-Scopes"))
+	granted := splitScopes(header.Get("X-OAuth-Scopes"))
+	for _, s := range accepted {
+		for _, g := range granted {
+			if s == g {
+				return nil
+			}
+		}
+	}
+	return accepted
+}
+
+func splitScopes(s string) []string {
+	var scopes []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			scopes = append(scopes, part)
+		}
+	}
+	return scopes
+}
+
+// classifyMessage guesses the kind of a failure from its message, for errors
+// that come without a status code.
+func classifyMessage(msg string) error {
+	lower := strings.ToLower(msg)
+	switch {
+	case isRateLimitMessage(lower):
+		return ErrRateLimited
+	case strings.Contains(lower, "saml"):
+		return ErrSSO
+	case strings.Contains(lower, "could not resolve"), strings.Contains(lower, "not found"):
+		return ErrNotFound
+	case strings.Contains(lower, "required scopes"), strings.Contains(lower, "bad credentials"),
+		strings.Contains(lower, "authentication"), strings.Contains(lower, "gh auth login"):
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// graphQLError is an entry of the "errors" list of a GraphQL response.
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+var scopeListRegex = regexp.MustCompile(`scopes: \[([^\]]*)\]`)
+
+// newGraphQLError converts the errors of a GraphQL response. The kind is
+// taken from the first error.
+func newGraphQLError(errs []graphQLError) *APIError {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+	}
+	e := &APIError{Message: strings.Join(msgs, "; ")}
+
+	switch errs[0].Type {
+	case "NOT_FOUND":
+		e.Kind = ErrNotFound
+	case "FORBIDDEN":
+		e.Kind = ErrUnauthorized
+	case "INSUFFICIENT_SCOPES":
+		e.Kind = ErrUnauthorized
+		if m := scopeListRegex.FindStringSubmatch(errs[0].Message); m != nil {
+			e.Scopes = splitScopes(strings.NewReplacer("'", "", `"`, "").Replace(m[1]))
+		}
+	case "RATE_LIMITED":
+		e.Kind = ErrRateLimited
+	default:
+		e.Kind = classifyMessage(e.Message)
+	}
+	return e
+}
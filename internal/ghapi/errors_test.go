@@ -0,0 +1,244 @@
+package ghapi
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewAPIError(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		body    string
+		kind    error
+		message string
+		scopes  []string
+		ssoURL  string
+		reset   bool
+	}{
+		{
+			name:    "not found",
+			status:  404,
+			body:    `{"message":"Not Found"}`,
+			kind:    ErrNotFound,
+			message: "Not Found",
+		},
+		{
+			name:    "gone",
+			status:  410,
+			body:    `{"message":"This issue was deleted"}`,
+			kind:    ErrNotFound,
+			message: "This issue was deleted",
+		},
+		{
+			name:    "bad credentials",
+			status:  401,
+			body:    `{"message":"Bad credentials"}`,
+			kind:    ErrUnauthorized,
+			message: "Bad credentials",
+		},
+		{
+			name:    "validation",
+			status:  422,
+			body:    `{"message":"Validation Failed","errors":[{"resource":"PullRequest","code":"custom","message":"No commits between main and feature"}]}`,
+			kind:    ErrValidation,
+			message: "Validation Failed",
+		},
+		{
+			name:    "saml sso",
+			status:  403,
+			header:  http.Header{"X-Github-Sso": {"required; url=https://github.com/orgs/acme/sso?authorization_request=abc"}},
+			body:    `{"message":"Resource protected by organization SAML enforcement."}`,
+			kind:    ErrSSO,
+			message: "Resource protected by organization SAML enforcement.",
+			ssoURL:  "https://github.com/orgs/acme/sso?authorization_request=abc",
+		},
+		{
+			name:   "primary rate limit",
+			status: 403,
+			header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+			},
+			body:    `{"message":"API rate limit exceeded for user ID 1."}`,
+			kind:    ErrRateLimited,
+			message: "API rate limit exceeded for user ID 1.",
+			reset:   true,
+		},
+		{
+			name:    "secondary rate limit",
+			status:  429,
+			header:  http.Header{"Retry-After": {"60"}},
+			body:    `{"message":"You have exceeded a secondary rate limit."}`,
+			kind:    ErrRateLimited,
+			message: "You have exceeded a secondary rate limit.",
+			reset:   true,
+		},
+		{
+			name:    "rate limit without headers",
+			status:  403,
+			body:    `{"message":"API rate limit exceeded"}`,
+			kind:    ErrRateLimited,
+			message: "API rate limit exceeded",
+		},
+		{
+			name:   "missing scope",
+			status: 403,
+			header: http.Header{
+				"X-Accepted-Oauth-Scopes": {"read:project, project"},
+				"X-Oauth-Scopes":          {"repo, read:org"},
+			},
+			body:    `{"message":"Resource not accessible by integration"}`,
+			kind:    ErrUnauthorized,
+			message: "Resource not accessible by integration",
+			scopes:  []string{"read:project", "project"},
+		},
+		{
+			name:   "scope granted",
+			status: 403,
+			header: http.Header{
+				"X-Accepted-Oauth-Scopes": {"repo"},
+				"X-Oauth-Scopes":          {"repo, read:org"},
+			},
+			body:    `{"message":"Must have admin rights to Repository."}`,
+			kind:    ErrUnauthorized,
+			message: "Must have admin rights to Repository.",
+		},
+		{
+			name:    "server error without body",
+			status:  502,
+			kind:    nil,
+			message: "Bad Gateway",
+		},
+		{
+			name:    "unavailable with retry-after",
+			status:  503,
+			header:  http.Header{"Retry-After": {"30"}},
+			body:    `{"message":"Service Unavailable"}`,
+			kind:    nil,
+			message: "Service Unavailable",
+			reset:   true,
+		},
+		{
+			name:    "no header",
+			status:  404,
+			header:  nil,
+			body:    `not json`,
+			kind:    ErrNotFound,
+			message: "Not Found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newAPIError(tt.status, tt.header, []byte(tt.body))
+			if err.Kind != tt.kind {
+				t.Errorf("Kind = %v, want %v", err.Kind, tt.kind)
+			}
+			if tt.kind != nil && !errors.Is(err, tt.kind) {
+				t.Errorf("errors.Is(err, %v) = false", tt.kind)
+			}
+			if err.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", err.StatusCode, tt.status)
+			}
+			if err.Message != tt.message {
+				t.Errorf("Message = %q, want %q", err.Message, tt.message)
+			}
+			if !slices.Equal(err.Scopes, tt.scopes) {
+				t.Errorf("Scopes = %q, want %q", err.Scopes, tt.scopes)
+			}
+			if err.SSOURL != tt.ssoURL {
+				t.Errorf("SSOURL = %q, want %q", err.SSOURL, tt.ssoURL)
+			}
+			if got := !err.Reset.IsZero(); got != tt.reset {
+				t.Errorf("Reset = %v, want set: %v", err.Reset, tt.reset)
+			}
+		})
+	}
+}
+
+func TestNewAPIErrorFields(t *testing.T) {
+	body := `{"message":"Validation Failed","errors":[{"resource":"Label","field":"name","code":"invalid"},{"message":"A pull request already exists"}]}`
+	err := newAPIError(422, nil, []byte(body))
+
+	want := "Validation Failed (HTTP 422): name invalid; A pull request already exists"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestNewAPIErrorRateLimitReset(t *testing.T) {
+	reset := time.Unix(1893456000, 0)
+	header := http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {"1893456000"},
+	}
+	err := newAPIError(403, header, []byte(`{"message":"API rate limit exceeded"}`))
+	if !err.Reset.Equal(reset) {
+		t.Errorf("Reset = %v, want %v", err.Reset, reset)
+	}
+}
+
+func TestNewGraphQLError(t *testing.T) {
+	tests := []struct {
+		name   string
+		errs   []graphQLError
+		kind   error
+		scopes []string
+	}{
+		{
+			name: "not found",
+			errs: []graphQLError{{Type: "NOT_FOUND", Message: "Could not resolve to an Issue with the number of 999."}},
+			kind: ErrNotFound,
+		},
+		{
+			name:   "insufficient scopes",
+			errs:   []graphQLError{{Type: "INSUFFICIENT_SCOPES", Message: "Your token has not been granted the required scopes to execute this query. The 'title' field requires one of the following scopes: ['read:project'], but your token has only been granted the: ['repo'] scopes."}},
+			kind:   ErrUnauthorized,
+			scopes: []string{"read:project"},
+		},
+		{
+			name: "rate limited",
+			errs: []graphQLError{{Type: "RATE_LIMITED", Message: "API rate limit exceeded"}},
+			kind: ErrRateLimited,
+		},
+		{
+			name: "untyped",
+			errs: []graphQLError{{Message: "Field 'subIssues' doesn't exist on type 'Issue'"}},
+			kind: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newGraphQLError(tt.errs)
+			if err.Kind != tt.kind {
+				t.Errorf("Kind = %v, want %v", err.Kind, tt.kind)
+			}
+			if !slices.Equal(err.Scopes, tt.scopes) {
+				t.Errorf("Scopes = %q, want %q", err.Scopes, tt.scopes)
+			}
+		})
+	}
+}
+
+func TestIsSchemaError(t *testing.T) {
+	schema := newGraphQLError([]graphQLError{{Message: "Field 'subIssues' doesn't exist on type 'Issue'"}})
+	if !isSchemaError(schema) {
+		t.Errorf("isSchemaError(%v) = false, want true", schema)
+	}
+	notFound := newAPIError(404, nil, nil)
+	if isSchemaError(notFound) {
+		t.Errorf("isSchemaError(%v) = true, want false", notFound)
+	}
+	if isSchemaError(errors.New("connection reset")) {
+		t.Error("isSchemaError of a plain error = true, want false")
+	}
+}
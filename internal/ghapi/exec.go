@@ -0,0 +1,305 @@
+package ghapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExecClient implements Client by running the gh CLI. It relies on gh for
+// authentication and is used when no API token can be found.
+type ExecClient struct {
+	// Host is the GitHub host commands are run against.
+	Host string
+}
+
+// NewExecClient returns a client that shells out to gh for the given host.
+func NewExecClient(host string) *ExecClient {
+	return &ExecClient{Host: host}
+}
+
+// run runs gh and returns its stdout. On failure the error is an *APIError
+// decoded from gh's output.
+func (c *ExecClient) run(args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("gh", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, execError(stdout.Bytes(), stderr.String(), err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// read runs a gh command that only reads data, retrying transient failures.
+func (c *ExecClient) read(args ...string) ([]byte, error) {
+	var out []byte
+	err := withRetry(func() error {
+		var err error
+		out, err = c.run(args...)
+		return err
+	})
+	return out, err
+}
+
+// api runs a GET request with `gh api` against the client's host.
+func (c *ExecClient) api(args ...string) ([]byte, error) {
+	if !isDefaultHost(c.Host) {
+		args = append([]string{"--hostname", c.Host}, args...)
+	}
+	return c.read(append([]string{"api"}, args...)...)
+}
+
+var httpStatusRegex = regexp.MustCompile(`\(HTTP (\d{3})\)`)
+
+// execError builds an error from a failed gh command. `gh api` prints the
+// response body on stdout and the status on stderr; other commands only
+// print a message on stderr.
+func execError(stdout []byte, stderr string, err error) error {
+	msg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(stderr), "gh: "))
+	if msg == "" {
+		msg = err.Error()
+	}
+
+	var gql struct {
+		Errors []graphQLError `json:"errors"`
+	}
+	if json.Unmarshal(stdout, &gql) == nil && len(gql.Errors) > 0 {
+		return newGraphQLError(gql.Errors)
+	}
+
+	status := 0
+	if m := httpStatusRegex.FindStringSubmatch(msg); m != nil {
+		status, _ = strconv.Atoi(m[1])
+	}
+	if status != 0 && json.Valid(stdout) {
+		return newAPIError(status, nil, stdout)
+	}
+	return &APIError{Kind: classifyMessage(msg), Message: msg}
+}
+
+// repoArg returns the --repo value for repo, qualified with the host when it
+// is not github.com.
+func (c *ExecClient) repoArg(repo string) string {
+	if isDefaultHost(c.Host) {
+		return repo
+	}
+	return c.Host + "/" + repo
+}
+
+// GetRepo fetches details of a repository, including its fork parent.
+func (c *ExecClient) GetRepo(repo string) (*Repository, error) {
+	out, err := c.api("repos/" + repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repository %s: %w", repo, err)
+	}
+	var r Repository
+	if err := json.Unmarshal(out, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse repository: %w", err)
+	}
+	return &r, nil
+}
+
+// GetIssue fetches details of a GitHub issue by number.
+func (c *ExecClient) GetIssue(repo string, number int) (*Issue, error) {
+	out, err := c.api(fmt.Sprintf("repos/%s/issues/%d", repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issue #%d: %w", number, err)
+	}
+	var issue issueResponse
+	if err := json.Unmarshal(out, &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse issue: %w", err)
+	}
+	if issue.PullRequest != nil {
+		return nil, fmt.Errorf("issue #%d %w", number, ErrPullRequest)
+	}
+	return &issue.Issue, nil
+}
+
+// GetIssueContext fetches an issue and its related data in one query.
+func (c *ExecClient) GetIssueContext(repo string, number int) (*IssueContext, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	query := func(withProjects bool) (*issueContextData, error) {
+		out, err := c.api("graphql",
+			"-f", "query="+issueContextQuery(withProjects),
+			"-f", "owner="+owner,
+			"-f", "name="+name,
+			"-F", "number="+strconv.Itoa(number),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var data issueContextData
+		if err := decodeGraphQL(out, &data); err != nil {
+			return nil, err
+		}
+		return &data, nil
+	}
+
+	data, err := query(true)
+	if isProjectScopeError(err) {
+		data, err = query(false)
+	}
+	if isSchemaError(err) {
+		return restIssueContext(c, repo, number)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issue #%d: %w", number, err)
+	}
+	return data.issueContext(number)
+}
+
+// ListIssues lists open issues matching the filter, newest first.
+func (c *ExecClient) ListIssues(repo string, f IssueFilter) (*IssueList, error) {
+	limit := f.limit()
+	// Ask for one more issue than the limit to tell whether there are more
+	out, err := c.read("issue", "list",
+		"--repo", c.repoArg(repo),
+		"--state", "open",
+		"--search", f.terms(repo),
+		"--limit", strconv.Itoa(limit+1),
+		"--json", "number,title,labels,milestone,state,url",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+	var issues []Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("failed to parse issues: %w", err)
+	}
+	list := &IssueList{Issues: issues}
+	if len(issues) > limit {
+		list.Issues = issues[:limit]
+		list.Truncated = true
+	}
+	return list, nil
+}
+
+// CreatePR creates a pull request via the gh CLI.
+func (c *ExecClient) CreatePR(repo, title, body, base, head string, draft bool, labels []string) (*PullRequest, error) {
+	args := []string{"pr", "create",
+		"--repo", c.repoArg(repo),
+		"--title", title,
+		"--body", body,
+		"--base", base,
+		"--head", head,
+	}
+	if draft {
+		args = append(args, "--draft")
+	}
+	for _, l := range labels {
+		args = append(args, "--label", l)
+	}
+	pr, err := createPRSafely(
+		func() (*PullRequest, error) {
+			out, err := c.run(args...)
+			if err != nil {
+				return nil, err
+			}
+			// gh pr create outputs the PR URL on success
+			return &PullRequest{URL: strings.TrimSpace(string(out)), Title: title}, nil
+		},
+		func() (*PullRequest, error) { return c.findOpenPR(repo, head) },
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PR: %w", err)
+	}
+
+	// Try to extract PR number from URL
+	parts := strings.Split(pr.URL, "/")
+	if len(parts) > 0 {
+		if num, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+			pr.Number = num
+		}
+	}
+	return pr, nil
+}
+
+// findOpenPR returns the open pull request for the head branch, or nil if
+// there is none. `gh pr list --head` only matches the branch name, so pull
+// requests from other forks are told apart by the owner of their head.
+func (c *ExecClient) findOpenPR(repo, head string) (*PullRequest, error) {
+	owner, branch, ok := strings.Cut(head, ":")
+	if !ok {
+		owner, _, _ = strings.Cut(repo, "/")
+		branch = head
+	}
+	out, err := c.read("pr", "list",
+		"--repo", c.repoArg(repo),
+		"--head", branch,
+		"--state", "open",
+		"--json", "number,title,url,headRepositoryOwner",
+	)
+	if err != nil {
+		return nil, err
+	}
+	var prs []listedPR
+	if err := json.Unmarshal(out, &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse pull requests: %w", err)
+	}
+	return prWithHeadOwner(prs, owner), nil
+}
+
+// listedPR is a pull request as printed by `gh pr list --json`.
+type listedPR struct {
+	Number              int    `json:"number"`
+	Title               string `json:"title"`
+	URL                 string `json:"url"`
+	HeadRepositoryOwner struct {
+		Login string `json:"login"`
+	} `json:"headRepositoryOwner"`
+}
+
+// prWithHeadOwner returns the first pull request whose head branch belongs
+// to owner, or nil if there is none.
+func prWithHeadOwner(prs []listedPR, owner string) *PullRequest {
+	for _, pr := range prs {
+		if strings.EqualFold(pr.HeadRepositoryOwner.Login, owner) {
+			return &PullRequest{Number: pr.Number, Title: pr.Title, URL: pr.URL}
+		}
+	}
+	return nil
+}
+
+// ListLabels lists available labels for a repository.
+func (c *ExecClient) ListLabels(repo string) ([]Label, error) {
+	out, err := c.api(fmt.Sprintf("repos/%s/labels", repo), "--paginate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	var labels []Label
+	if err := json.Unmarshal(out, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels: %w", err)
+	}
+	return labels, nil
+}
+
+// CurrentUser returns the currently authenticated GitHub username.
+func (c *ExecClient) CurrentUser() (string, error) {
+	out, err := c.api("user", "--jq", ".login")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// LinkBranchToIssue creates a branch in branchRepo linked to an issue of
+// issueRepo using `gh issue develop`. The branch must NOT exist on the
+// remote yet.
+func (c *ExecClient) LinkBranchToIssue(issueRepo string, issueNumber int, branchRepo, branchName, baseBranch string) error {
+	_, err := c.run(
+		"issue", "develop", strconv.Itoa(issueNumber),
+		"--repo", c.repoArg(issueRepo),
+		"--branch-repo", c.repoArg(branchRepo),
+		"--name", branchName,
+		"--base", baseBranch,
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
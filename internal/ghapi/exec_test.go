@@ -0,0 +1,35 @@
+package ghapi
+
+import "testing"
+
+func TestPRWithHeadOwner(t *testing.T) {
+	pr := func(number int, owner string) listedPR {
+		p := listedPR{Number: number}
+		p.HeadRepositoryOwner.Login = owner
+		return p
+	}
+	tests := []struct {
+		name  string
+		prs   []listedPR
+		owner string
+		want  int
+	}{
+		{name: "own pull request", prs: []listedPR{pr(7, "octo")}, owner: "octo", want: 7},
+		{name: "owner differs in case", prs: []listedPR{pr(7, "Octo")}, owner: "octo", want: 7},
+		{name: "only a fork's pull request", prs: []listedPR{pr(9, "someone")}, owner: "octo"},
+		{name: "fork's pull request listed first", prs: []listedPR{pr(9, "someone"), pr(7, "octo")}, owner: "octo", want: 7},
+		{name: "none", owner: "octo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prWithHeadOwner(tt.prs, tt.owner)
+			switch {
+			case tt.want == 0 && got != nil:
+				t.Errorf("prWithHeadOwner() = #%d, want nil", got.Number)
+			case tt.want != 0 && (got == nil || got.Number != tt.want):
+				t.Errorf("prWithHeadOwner() = %v, want #%d", got, tt.want)
+			}
+		})
+	}
+}
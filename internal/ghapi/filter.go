@@ -0,0 +1,93 @@
+package ghapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultIssueLimit is the number of issues listed when no limit is given.
+const DefaultIssueLimit = 100
+
+// Special values of IssueFilter.Assignee.
+const (
+	AssigneeMe   = "@me"
+	AssigneeNone = "none"
+	AssigneeAny  = "*"
+)
+
+// IssueFilter selects the open issues listed by ListIssues.
+type IssueFilter struct {
+	// Labels must all be present on the issue.
+	Labels []string
+	// Milestone is a milestone title, or "none" for issues without one.
+	Milestone string
+	// Project is a project as "owner/number", or just its number for a
+	// project owned by the repository owner.
+	Project string
+	// Assignee is a login, AssigneeMe, AssigneeNone or AssigneeAny. Empty
+	// means AssigneeMe.
+	Assignee string
+	// Search is free text matched against titles and bodies.
+	Search string
+	// Limit caps the number of issues returned; 0 means DefaultIssueLimit.
+	Limit int
+}
+
+// IssueList is the result of ListIssues.
+type IssueList struct {
+	Issues []Issue
+	// Truncated is set when more issues matched than the limit allowed.
+	Truncated bool
+}
+
+func (f IssueFilter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultIssueLimit
+	}
+	return f.Limit
+}
+
+// terms returns the filter as GitHub search qualifiers, without the
+// repository and state.
+func (f IssueFilter) terms(repo string) string {
+	var terms []string
+	switch f.Assignee {
+	case "":
+		terms = append(terms, qualifier("assignee", AssigneeMe))
+	case AssigneeNone:
+		terms = append(terms, "no:assignee")
+	case AssigneeAny:
+	default:
+		terms = append(terms, qualifier("assignee", f.Assignee))
+	}
+	for _, l := range f.Labels {
+		terms = append(terms, qualifier("label", l))
+	}
+	switch f.Milestone {
+	case "":
+	case "none":
+		terms = append(terms, "no:milestone")
+	default:
+		terms = append(terms, qualifier("milestone", f.Milestone))
+	}
+	if f.Project != "" {
+		project := f.Project
+		if !strings.Contains(project, "/") {
+			owner, _, _ := strings.Cut(repo, "/")
+			project = owner + "/" + project
+		}
+		terms = append(terms, qualifier("project", project))
+	}
+	if f.Search != "" {
+		terms = append(terms, f.Search)
+	}
+	return strings.Join(terms, " ")
+}
+
+// qualifier formats a search qualifier, quoting values with spaces.
+func qualifier(name, value string) string {
+	if strings.ContainsAny(value, " \t") {
+		return fmt.Sprintf("%s:%q", name, value)
+	}
+	return name + ":" + value
+}
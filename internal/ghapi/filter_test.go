@@ -0,0 +1,47 @@
+package ghapi
+
+import "testing"
+
+func TestIssueFilterTerms(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter IssueFilter
+		want   string
+	}{
+		{name: "default assignee", want: "assignee:@me"},
+		{name: "any assignee", filter: IssueFilter{Assignee: AssigneeAny}, want: ""},
+		{name: "unassigned", filter: IssueFilter{Assignee: AssigneeNone}, want: "no:assignee"},
+		{name: "login", filter: IssueFilter{Assignee: "octocat"}, want: "assignee:octocat"},
+		{
+			name:   "labels with spaces",
+			filter: IssueFilter{Assignee: AssigneeAny, Labels: []string{"bug", "good first issue"}},
+			want:   `label:bug label:"good first issue"`,
+		},
+		{name: "milestone", filter: IssueFilter{Assignee: AssigneeAny, Milestone: "Sprint 12"}, want: `milestone:"Sprint 12"`},
+		{name: "no milestone", filter: IssueFilter{Assignee: AssigneeAny, Milestone: "none"}, want: "no:milestone"},
+		{name: "project number", filter: IssueFilter{Assignee: AssigneeAny, Project: "4"}, want: "project:octo/4"},
+		{name: "project of another owner", filter: IssueFilter{Assignee: AssigneeAny, Project: "acme/2"}, want: "project:acme/2"},
+		{
+			name:   "everything",
+			filter: IssueFilter{Assignee: AssigneeNone, Labels: []string{"bug"}, Milestone: "v1", Project: "4", Search: "login timeout"},
+			want:   "no:assignee label:bug milestone:v1 project:octo/4 login timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.terms("octo/demo"); got != tt.want {
+				t.Errorf("terms() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIssueFilterLimit(t *testing.T) {
+	if got := (IssueFilter{}).limit(); got != DefaultIssueLimit {
+		t.Errorf("limit() = %d, want %d", got, DefaultIssueLimit)
+	}
+	if got := (IssueFilter{Limit: 250}).limit(); got != 250 {
+		t.Errorf("limit() = %d, want 250", got)
+	}
+}
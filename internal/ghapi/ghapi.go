@@ -1,138 +1,126 @@
 package ghapi
 
 import (
-	"encoding/json"
 	"fmt"
-	"os/exec"
-	"strconv"
-	"strings"
+	"os"
 )
 
-// Issue represents a GitHub issue.
-type Issue struct {
-	Number int     `json:"number"`
-	Title  string  `json:"title"`
-	Body   string  `json:"body"`
-	Labels []Label `json:"labels"`
-	State  string  `json:"state"`
-	URL    string  `json:"html_url"`
+// Client is the set of GitHub operations used by gh-buddy. A client is bound
+// to one GitHub host; repo is an "owner/repo" slug on that host.
+type Client interface {
+	// GetRepo fetches details of a repository, including its fork parent.
+	GetRepo(repo string) (*Repository, error)
+	// GetIssue fetches details of a GitHub issue by number.
+	GetIssue(repo string, number int) (*Issue, error)
+	// GetIssueContext fetches an issue together with its assignees, projects,
+	// linked branches and pull requests, parent and sub-issues, and the
+	// repository's default branch, in a single request.
+	GetIssueContext(repo string, number int) (*IssueContext, error)
+	// ListIssues lists open issues matching the filter, newest first.
+	ListIssues(repo string, f IssueFilter) (*IssueList, error)
+	// CreatePR creates a pull request and adds the given labels to it.
+	CreatePR(repo, title, body, base, head string, draft bool, labels []string) (*PullRequest, error)
+	// ListLabels lists available labels for a repository.
+	ListLabels(repo string) ([]Label, error)
+	// CurrentUser returns the currently authenticated GitHub username.
+	CurrentUser() (string, error)
+	// LinkBranchToIssue creates a branch in branchRepo linked to an issue of
+	// issueRepo. The branch must NOT exist on the remote yet.
+	LinkBranchToIssue(issueRepo string, issueNumber int, branchRepo, branchName, baseBranch string) error
 }
 
-// Label represents a GitHub issue label.
-type Label struct {
-	Name string `json:"name"`
+// New returns a client for the given host. It talks to the API directly when
+// a token is available, keeping issues in cache if not nil, and falls back to
+// shelling out to gh otherwise. When ReplayEnv is set, responses come from
+// that fixture and nothing goes over the network; when RecordEnv is set,
+// every exchange is written to that fixture. The cache is not used in
+// either mode.
+func New(host string, cache *Cache) (Client, error) {
+	if path := os.Getenv(ReplayEnv); path != "" {
+		replayer, err := LoadReplayer(path)
+		if err != nil {
+			return nil, err
+		}
+		c := NewHTTPClient(host, "")
+		c.HTTP.Transport = replayer
+		return c, nil
+	}
+
+	token, err := Token(host)
+	if path := os.Getenv(RecordEnv); path != "" {
+		if err != nil {
+			return nil, fmt.Errorf("recording needs an API token: %w", err)
+		}
+		c := NewHTTPClient(host, token)
+		c.HTTP.Transport = &Recorder{Path: path}
+		return c, nil
+	}
+	if err == nil {
+		c := NewHTTPClient(host, token)
+		c.Cache = cache
+		return c, nil
+	}
+	return NewExecClient(host), nil
 }
 
-// PullRequest represents a created pull request.
-type PullRequest struct {
-	Number int    `json:"number"`
-	URL    string `json:"html_url"`
-	Title  string `json:"title"`
+// Repository represents a GitHub repository.
+type Repository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+	Fork          bool   `json:"fork"`
+	// Parent is the repository this one was forked from, if any.
+	Parent *Repository `json:"parent"`
 }
 
-// GetIssue fetches details of a GitHub issue by number.
-func GetIssue(repo string, number int) (*Issue, error) {
-	out, err := exec.Command("gh", "api",
-		fmt.Sprintf("repos/%s/issues/%d", repo, number),
-	).Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch issue #%d: %w", number, err)
-	}
-	var issue Issue
-	if err := json.Unmarshal(out, &issue); err != nil {
-		return nil, fmt.Errorf("failed to parse issue: %w", err)
-	}
-	return &issue, nil
+// Issue represents a GitHub issue.
+type Issue struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Labels    []Label    `json:"labels"`
+	Milestone *Milestone `json:"milestone"`
+	State     string     `json:"state"`
+	URL       string     `json:"html_url"`
 }
 
-// ListOpenIssues lists open issues assigned to the current user.
-func ListOpenIssues(repo string) ([]Issue, error) {
-	out, err := exec.Command("gh", "issue", "list",
-		"--repo", repo,
-		"--assignee", "@me",
-		"--state", "open",
-		"--json", "number,title,labels,state,url",
-	).Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list issues: %w", err)
-	}
-	var issues []Issue
-	if err := json.Unmarshal(out, &issues); err != nil {
-		return nil, fmt.Errorf("failed to parse issues: %w", err)
+// MilestoneTitle returns the title of the issue's milestone, if any.
+func (i *Issue) MilestoneTitle() string {
+	if i.Milestone == nil {
+		return ""
 	}
-	return issues, nil
+	return i.Milestone.Title
 }
 
-// CreatePR creates a pull request via the gh CLI.
-func CreatePR(repo, title, body, base, head string, draft bool, labels []string) (*PullRequest, error) {
-	args := []string{"pr", "create",
-		"--repo", repo,
-		"--title", title,
-		"--body", body,
-		"--base", base,
-		"--head", head,
+// LabelNames returns the names of the issue's labels.
+func (i *Issue) LabelNames() []string {
+	names := make([]string, len(i.Labels))
+	for j, l := range i.Labels {
+		names[j] = l.Name
 	}
-	if draft {
-		args = append(args, "--draft")
-	}
-	for _, l := range labels {
-		args = append(args, "--label", l)
-	}
-	out, err := exec.Command("gh", args...).CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create PR: %s: %w", string(out), err)
-	}
-	// gh pr create outputs the PR URL on success
-	url := strings.TrimSpace(string(out))
-	pr := &PullRequest{URL: url}
+	return names
+}
 
-	// Try to extract PR number from URL
-	parts := strings.Split(url, "/")
-	if len(parts) > 0 {
-		if num, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
-			pr.Number = num
-		}
-	}
-	pr.Title = title
-	return pr, nil
+// issueResponse is an item returned by the REST issues endpoints, which also
+// return pull requests.
+type issueResponse struct {
+	Issue
+	PullRequest *struct{} `json:"pull_request"`
 }
 
-// ListLabels lists available labels for a repository.
-func ListLabels(repo string) ([]Label, error) {
-	out, err := exec.Command("gh", "api",
-		fmt.Sprintf("repos/%s/labels", repo),
-		"--paginate",
-	).Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list labels: %w", err)
-	}
-	var labels []Label
-	if err := json.Unmarshal(out, &labels); err != nil {
-		return nil, fmt.Errorf("failed to parse labels: %w", err)
-	}
-	return labels, nil
+// Label represents a GitHub issue label.
+type Label struct {
+	Name string `json:"name"`
 }
 
-// CurrentUser returns the currently authenticated GitHub username.
-func CurrentUser() (string, error) {
-	out, err := exec.Command("gh", "api", "user", "--jq", ".login").Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get current user: %w", err)
-	}
-	return strings.TrimSpace(string(out)), nil
+// Milestone represents a GitHub milestone.
+type Milestone struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
 }
 
-// LinkBranchToIssue creates a remote branch on GitHub linked to the given issue
-// using `gh issue develop`. The branch must NOT exist on the remote yet.
-func LinkBranchToIssue(repo string, issueNumber int, branchName, baseBranch string) error {
-	out, err := exec.Command(
-		"gh", "issue", "develop", strconv.Itoa(issueNumber),
-		"--repo", repo,
-		"--name", branchName,
-		"--base", baseBranch,
-	).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%s: %w", strings.TrimSpace(string(out)), err)
-	}
-	return nil
+// PullRequest represents a created pull request.
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+	Title  string `json:"title"`
 }
@@ -0,0 +1,469 @@
+package ghapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPClient implements Client with direct calls to the GitHub REST and
+// GraphQL APIs.
+type HTTPClient struct {
+	// BaseURL is the root of the REST API, ending in "/".
+	BaseURL string
+	// GraphQLURL is the GraphQL endpoint.
+	GraphQLURL string
+	// Host is the GitHub host, used in cache keys.
+	Host  string
+	Token string
+	HTTP  *http.Client
+	// Cache, if set, stores issues between runs.
+	Cache *Cache
+}
+
+// NewHTTPClient returns a client for the API of host using token.
+func NewHTTPClient(host, token string) *HTTPClient {
+	c := &HTTPClient{
+		Host:  host,
+		Token: token,
+		HTTP:  &http.Client{Timeout: 30 * time.Second},
+	}
+	if isDefaultHost(host) {
+		c.BaseURL = "https://api.github.com/"
+		c.GraphQLURL = "https://api.github.com/graphql"
+	} else {
+		c.BaseURL = fmt.Sprintf("https://%s/api/v3/", host)
+		c.GraphQLURL = fmt.Sprintf("https://%s/api/graphql", host)
+	}
+	return c
+}
+
+// do sends a request to the REST API and decodes the JSON response into out,
+// if not nil. path is relative to BaseURL unless it is an absolute URL.
+func (c *HTTPClient) do(method, path string, body, out any) (*http.Response, error) {
+	target := path
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		target = c.BaseURL + strings.TrimPrefix(path, "/")
+	}
+	return c.send(method, target, body, out)
+}
+
+// send sends a request, retrying transient failures of GET requests.
+func (c *HTTPClient) send(method, target string, body, out any) (*http.Response, error) {
+	if method != http.MethodGet {
+		return c.sendOnce(method, target, body, out)
+	}
+	var resp *http.Response
+	err := withRetry(func() error {
+		var err error
+		resp, err = c.sendOnce(method, target, body, out)
+		return err
+	})
+	return resp, err
+}
+
+func (c *HTTPClient) sendOnce(method, target string, body, out any) (*http.Response, error) {
+	resp, data, err := c.fetch(method, target, nil, body)
+	if err != nil {
+		return resp, err
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// fetch sends a request with the extra header and returns the response and
+// its body. Responses other than 2xx and 304 Not Modified are errors.
+func (c *HTTPClient) fetch(method, target string, header http.Header, body any) (*http.Response, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "gh-buddy")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, err
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+		return resp, data, newAPIError(resp.StatusCode, resp.Header, data)
+	}
+	return resp, data, nil
+}
+
+// cachedGet fetches a JSON response through the cache and decodes it into
+// out. Cached data is revalidated with If-None-Match once older than the TTL,
+// and served with a warning when GitHub cannot be reached.
+func (c *HTTPClient) cachedGet(key, path string, out any) error {
+	if c.Cache == nil {
+		_, err := c.do(http.MethodGet, path, nil, out)
+		return err
+	}
+
+	entry, cached := c.Cache.load(key)
+	if cached && c.Cache.fresh(entry) {
+		return json.Unmarshal(entry.Body, out)
+	}
+
+	header := http.Header{}
+	if cached && entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	var resp *http.Response
+	var data []byte
+	err := withRetry(func() error {
+		var err error
+		resp, data, err = c.fetch(http.MethodGet, c.BaseURL+path, header, nil)
+		return err
+	})
+	switch {
+	case err != nil && cached && isUnavailable(err):
+		return c.Cache.stale(entry, err, out)
+	case err != nil:
+		return err
+	case resp.StatusCode == http.StatusNotModified && cached:
+		entry.FetchedAt = time.Now()
+		c.Cache.store(key, entry)
+		return json.Unmarshal(entry.Body, out)
+	}
+
+	c.Cache.store(key, &cacheEntry{ETag: resp.Header.Get("ETag"), FetchedAt: time.Now(), Body: data})
+	return json.Unmarshal(data, out)
+}
+
+// cachedQuery runs a GraphQL query through the cache. GraphQL responses have
+// no ETag, so cached data is used as is while fresh, or with a warning when
+// GitHub cannot be reached. It reports whether out was decoded from a fresh
+// cache entry without asking GitHub.
+func (c *HTTPClient) cachedQuery(key, query string, variables map[string]any, out any) (bool, error) {
+	if c.Cache == nil {
+		return false, c.graphQL(query, variables, out)
+	}
+
+	entry, cached := c.Cache.load(key)
+	if cached && c.Cache.fresh(entry) {
+		return true, json.Unmarshal(entry.Body, out)
+	}
+
+	var data json.RawMessage
+	err := c.graphQL(query, variables, &data)
+	switch {
+	case err != nil && cached && isUnavailable(err):
+		return false, c.Cache.stale(entry, err, out)
+	case err != nil:
+		return false, err
+	}
+
+	c.Cache.store(key, &cacheEntry{FetchedAt: time.Now(), Body: data})
+	return false, json.Unmarshal(data, out)
+}
+
+var nextLinkRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// getAll follows the pagination links of a list endpoint, appending each
+// page to out.
+func getAll[T any](c *HTTPClient, path string, out *[]T) error {
+	for path != "" {
+		var page []T
+		resp, err := c.do(http.MethodGet, path, nil, &page)
+		if err != nil {
+			return err
+		}
+		*out = append(*out, page...)
+
+		path = ""
+		if m := nextLinkRegex.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			path = m[1]
+		}
+	}
+	return nil
+}
+
+// graphQL runs a GraphQL query or mutation and decodes its "data" field into
+// out. Queries are retried on transient failures; mutations are not.
+func (c *HTTPClient) graphQL(query string, variables map[string]any, out any) error {
+	run := func() error {
+		var resp struct {
+			Data   json.RawMessage `json:"data"`
+			Errors []graphQLError  `json:"errors"`
+		}
+		body := map[string]any{"query": query, "variables": variables}
+		if _, err := c.sendOnce(http.MethodPost, c.GraphQLURL, body, &resp); err != nil {
+			return err
+		}
+		if len(resp.Errors) > 0 {
+			return newGraphQLError(resp.Errors)
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Data, out)
+	}
+	if strings.HasPrefix(strings.TrimSpace(query), "mutation") {
+		return run()
+	}
+	return withRetry(run)
+}
+
+// GetRepo fetches details of a repository, including its fork parent.
+func (c *HTTPClient) GetRepo(repo string) (*Repository, error) {
+	var r Repository
+	path := "repos/" + repo
+	if err := c.cachedGet(c.Host+"/"+path, path, &r); err != nil {
+		return nil, fmt.Errorf("failed to fetch repository %s: %w", repo, err)
+	}
+	return &r, nil
+}
+
+// GetIssue fetches details of a GitHub issue by number.
+func (c *HTTPClient) GetIssue(repo string, number int) (*Issue, error) {
+	var issue issueResponse
+	path := fmt.Sprintf("repos/%s/issues/%d", repo, number)
+	if err := c.cachedGet(c.Host+"/"+path, path, &issue); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue #%d: %w", number, err)
+	}
+	if issue.PullRequest != nil {
+		return nil, fmt.Errorf("issue #%d %w", number, ErrPullRequest)
+	}
+	return &issue.Issue, nil
+}
+
+// GetIssueContext fetches an issue and its related data in one query.
+func (c *HTTPClient) GetIssueContext(repo string, number int) (*IssueContext, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	vars := map[string]any{"owner": owner, "name": name, "number": number}
+	key := fmt.Sprintf("%s/repos/%s/issues/%d-context", c.Host, repo, number)
+
+	var data issueContextData
+	cached, err := c.cachedQuery(key, issueContextQuery(true), vars, &data)
+	if isProjectScopeError(err) {
+		cached, err = c.cachedQuery(key, issueContextQuery(false), vars, &data)
+	}
+	if isSchemaError(err) {
+		return restIssueContext(c, repo, number)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issue #%d: %w", number, err)
+	}
+
+	// Linked branches and pull requests must be current, so a cached context
+	// only saves fetching the issue itself.
+	if cached {
+		var links issueContextData
+		err := c.graphQL(issueLinksQuery, vars, &links)
+		switch {
+		case err != nil && isUnavailable(err):
+			c.Cache.warn("Could not reach GitHub (%v); linked branches and pull requests may be out of date", err)
+		case err != nil:
+			return nil, fmt.Errorf("failed to fetch issue #%d: %w", number, err)
+		default:
+			data.setLinks(&links)
+		}
+	}
+	return data.issueContext(number)
+}
+
+// ListIssues lists open issues matching the filter, using the search API.
+func (c *HTTPClient) ListIssues(repo string, f IssueFilter) (*IssueList, error) {
+	q := fmt.Sprintf("repo:%s is:issue is:open %s", repo, f.terms(repo))
+	limit := f.limit()
+	perPage := min(limit, 100)
+	hash := sha256.Sum256([]byte(q))
+
+	list := &IssueList{}
+	for page := 1; len(list.Issues) < limit; page++ {
+		var result struct {
+			TotalCount int     `json:"total_count"`
+			Items      []Issue `json:"items"`
+		}
+		query := url.Values{
+			"q":        {q},
+			"sort":     {"created"},
+			"order":    {"desc"},
+			"per_page": {strconv.Itoa(perPage)},
+			"page":     {strconv.Itoa(page)},
+		}
+		key := fmt.Sprintf("%s/repos/%s/issues/search-%x-%d-%d", c.Host, repo, hash[:8], perPage, page)
+		if err := c.cachedGet(key, "search/issues?"+query.Encode(), &result); err != nil {
+			return nil, fmt.Errorf("failed to list issues: %w", err)
+		}
+		list.Issues = append(list.Issues, result.Items...)
+		list.Truncated = result.TotalCount > len(list.Issues)
+		if len(result.Items) < perPage {
+			break
+		}
+	}
+	if len(list.Issues) > limit {
+		list.Issues = list.Issues[:limit]
+		list.Truncated = true
+	}
+	return list, nil
+}
+
+// CreatePR creates a pull request and adds the given labels to it.
+func (c *HTTPClient) CreatePR(repo, title, body, base, head string, draft bool, labels []string) (*PullRequest, error) {
+	req := map[string]any{
+		"title": title,
+		"body":  body,
+		"base":  base,
+		"head":  head,
+		"draft": draft,
+	}
+	pr, err := createPRSafely(
+		func() (*PullRequest, error) {
+			var pr PullRequest
+			if _, err := c.do(http.MethodPost, fmt.Sprintf("repos/%s/pulls", repo), req, &pr); err != nil {
+				return nil, err
+			}
+			return &pr, nil
+		},
+		func() (*PullRequest, error) { return c.findOpenPR(repo, head) },
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PR: %w", err)
+	}
+
+	if len(labels) > 0 {
+		path := fmt.Sprintf("repos/%s/issues/%d/labels", repo, pr.Number)
+		if _, err := c.do(http.MethodPost, path, map[string]any{"labels": labels}, nil); err != nil {
+			return pr, fmt.Errorf("created PR %s but failed to add labels: %w", pr.URL, err)
+		}
+	}
+	return pr, nil
+}
+
+// findOpenPR returns the open pull request for the head branch, or nil if
+// there is none.
+func (c *HTTPClient) findOpenPR(repo, head string) (*PullRequest, error) {
+	if !strings.Contains(head, ":") {
+		owner, _, _ := strings.Cut(repo, "/")
+		head = owner + ":" + head
+	}
+	var prs []PullRequest
+	query := url.Values{"head": {head}, "state": {"open"}}
+	if _, err := c.do(http.MethodGet, fmt.Sprintf("repos/%s/pulls?%s", repo, query.Encode()), nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &prs[0], nil
+}
+
+// ListLabels lists available labels for a repository.
+func (c *HTTPClient) ListLabels(repo string) ([]Label, error) {
+	var labels []Label
+	if err := getAll(c, fmt.Sprintf("repos/%s/labels?per_page=100", repo), &labels); err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	return labels, nil
+}
+
+// CurrentUser returns the currently authenticated GitHub username.
+func (c *HTTPClient) CurrentUser() (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.cachedGet(c.Host+"/user", "user", &user); err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+	return user.Login, nil
+}
+
+// LinkBranchToIssue creates a branch in branchRepo linked to an issue of
+// issueRepo, like `gh issue develop`. The branch must NOT exist on the
+// remote yet.
+func (c *HTTPClient) LinkBranchToIssue(issueRepo string, issueNumber int, branchRepo, branchName, baseBranch string) error {
+	issueOwner, issueName, _ := strings.Cut(issueRepo, "/")
+	owner, name, _ := strings.Cut(branchRepo, "/")
+
+	var ids struct {
+		IssueRepository struct {
+			Issue struct {
+				ID string `json:"id"`
+			} `json:"issue"`
+		} `json:"issueRepository"`
+		Repository struct {
+			ID  string `json:"id"`
+			Ref *struct {
+				Target struct {
+					OID string `json:"oid"`
+				} `json:"target"`
+			} `json:"ref"`
+		} `json:"repository"`
+	}
+	const query = `query($issueOwner: String!, $issueName: String!, $number: Int!, $owner: String!, $name: String!, $ref: String!) {
+  issueRepository: repository(owner: $issueOwner, name: $issueName) {
+    issue(number: $number) { id }
+  }
+  repository(owner: $owner, name: $name) {
+    id
+    ref(qualifiedName: $ref) { target { oid } }
+  }
+}`
+	vars := map[string]any{
+		"issueOwner": issueOwner,
+		"issueName":  issueName,
+		"number":     issueNumber,
+		"owner":      owner,
+		"name":       name,
+		"ref":        "refs/heads/" + baseBranch,
+	}
+	if err := c.graphQL(query, vars, &ids); err != nil {
+		return fmt.Errorf("failed to look up issue #%d: %w", issueNumber, err)
+	}
+	if ids.Repository.Ref == nil {
+		return fmt.Errorf("base branch %q not found in %s", baseBranch, branchRepo)
+	}
+
+	const mutation = `mutation($input: CreateLinkedBranchInput!) {
+  createLinkedBranch(input: $input) { linkedBranch { id } }
+}`
+	input := map[string]any{
+		"issueId":      ids.IssueRepository.Issue.ID,
+		"repositoryId": ids.Repository.ID,
+		"oid":          ids.Repository.Ref.Target.OID,
+		"name":         branchName,
+	}
+	if err := c.graphQL(mutation, map[string]any{"input": input}, nil); err != nil {
+		return fmt.Errorf("failed to create linked branch %q: %w", branchName, err)
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package ghapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client talking to a test server that serves
+// handler for both the REST and GraphQL APIs.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *HTTPClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	sleep = func(time.Duration) {}
+	t.Cleanup(func() { sleep = time.Sleep })
+
+	c := NewHTTPClient("github.com", "test-token")
+	c.BaseURL = srv.URL + "/"
+	c.GraphQLURL = srv.URL + "/graphql"
+	return c
+}
+
+func TestHTTPClientGetIssue(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want the token", got)
+		}
+		switch r.URL.Path {
+		case "/repos/octo/demo/issues/42":
+			fmt.Fprint(w, `{"number":42,"title":"Login fails","state":"open","labels":[{"name":"bug"}],"milestone":{"number":3,"title":"v1.2"},"html_url":"https://github.com/octo/demo/issues/42"}`)
+		case "/repos/octo/demo/issues/43":
+			fmt.Fprint(w, `{"number":43,"title":"Fix login","pull_request":{"url":"https://api.github.com/repos/octo/demo/pulls/43"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	})
+
+	issue, err := c.GetIssue("octo/demo", 42)
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if issue.Title != "Login fails" || issue.MilestoneTitle() != "v1.2" || !slices.Equal(issue.LabelNames(), []string{"bug"}) {
+		t.Errorf("GetIssue() = %+v", issue)
+	}
+
+	if _, err := c.GetIssue("octo/demo", 43); !errors.Is(err, ErrPullRequest) {
+		t.Errorf("GetIssue() of a pull request error = %v, want ErrPullRequest", err)
+	}
+	if _, err := c.GetIssue("octo/demo", 44); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetIssue() of a missing issue error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHTTPClientRetriesServerErrors(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"full_name":"octo/demo","default_branch":"main"}`)
+	})
+
+	repo, err := c.GetRepo("octo/demo")
+	if err != nil {
+		t.Fatalf("GetRepo() error = %v", err)
+	}
+	if repo.DefaultBranch != "main" || calls != 2 {
+		t.Errorf("GetRepo() = %+v after %d requests, want main after 2", repo, calls)
+	}
+}
+
+func TestHTTPClientCreatePR(t *testing.T) {
+	var created map[string]any
+	var labels []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octo/demo/pulls":
+			if err := json.Unmarshal(body, &created); err != nil {
+				t.Errorf("invalid pull request body: %v", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"number":7,"html_url":"https://github.com/octo/demo/pull/7","title":"Fix login"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octo/demo/issues/7/labels":
+			var req struct {
+				Labels []string `json:"labels"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Errorf("invalid labels body: %v", err)
+			}
+			labels = req.Labels
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	pr, err := c.CreatePR("octo/demo", "Fix login", "Closes #42", "main", "fork:bugfix/GH-42", true, []string{"bug"})
+	if err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	if pr.Number != 7 || pr.URL != "https://github.com/octo/demo/pull/7" {
+		t.Errorf("CreatePR() = %+v", pr)
+	}
+	want := map[string]any{"title": "Fix login", "body": "Closes #42", "base": "main", "head": "fork:bugfix/GH-42", "draft": true}
+	for k, v := range want {
+		if created[k] != v {
+			t.Errorf("request %s = %v, want %v", k, created[k], v)
+		}
+	}
+	if !slices.Equal(labels, []string{"bug"}) {
+		t.Errorf("labels = %q, want [bug]", labels)
+	}
+}
+
+func TestHTTPClientCreatePRValidation(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"message":"Validation Failed","errors":[{"resource":"PullRequest","code":"custom","message":"No commits between main and feature"}]}`)
+	})
+
+	_, err := c.CreatePR("octo/demo", "Fix login", "", "main", "feature", false, nil)
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "No commits between main and feature") {
+		t.Errorf("CreatePR() error = %v, want a validation error with its details", err)
+	}
+}
+
+func TestHTTPClientGetIssueContext(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid GraphQL request: %v", err)
+		}
+		if req.Variables["owner"] != "octo" || req.Variables["name"] != "demo" || req.Variables["number"] != float64(42) {
+			t.Errorf("variables = %v", req.Variables)
+		}
+		fmt.Fprint(w, `{"data":{"viewer":{"login":"octocat"},"repository":{"nameWithOwner":"octo/demo","defaultBranchRef":{"name":"main"},"issueOrPullRequest":{
+			"__typename":"Issue","number":42,"title":"Login fails","state":"OPEN","url":"https://github.com/octo/demo/issues/42",
+			"labels":{"nodes":[{"name":"bug"}]},
+			"assignees":{"nodes":[{"login":"octocat"}]},
+			"projectItems":{"nodes":[{"project":{"title":"Roadmap"}}]},
+			"linkedBranches":{"nodes":[{"ref":{"name":"bugfix/GH-42-login-fails"}},{"ref":null}]},
+			"closedByPullRequestsReferences":{"nodes":[{"number":7,"title":"Fix login","url":"https://github.com/octo/demo/pull/7","state":"OPEN"},{"number":5,"state":"CLOSED"}]},
+			"parent":{"number":40,"title":"Auth epic","state":"OPEN","repository":{"nameWithOwner":"octo/demo"}},
+			"subIssues":{"nodes":[]}}}}}`)
+	})
+
+	ctx, err := c.GetIssueContext("octo/demo", 42)
+	if err != nil {
+		t.Fatalf("GetIssueContext() error = %v", err)
+	}
+	if ctx.Title != "Login fails" || ctx.State != "open" || ctx.Viewer != "octocat" || ctx.DefaultBranch != "main" {
+		t.Errorf("GetIssueContext() = %+v", ctx)
+	}
+	if !slices.Equal(ctx.Projects, []string{"Roadmap"}) {
+		t.Errorf("Projects = %q, want [Roadmap]", ctx.Projects)
+	}
+	if !slices.Equal(ctx.LinkedBranches, []string{"bugfix/GH-42-login-fails"}) {
+		t.Errorf("LinkedBranches = %q", ctx.LinkedBranches)
+	}
+	if len(ctx.LinkedPRs) != 1 || ctx.LinkedPRs[0].Number != 7 {
+		t.Errorf("LinkedPRs = %+v, want only the open #7", ctx.LinkedPRs)
+	}
+	if ctx.Parent == nil || ctx.Parent.String() != "octo/demo#40" {
+		t.Errorf("Parent = %v, want octo/demo#40", ctx.Parent)
+	}
+}
+
+func TestHTTPClientGetIssueContextCached(t *testing.T) {
+	var full, links int
+	linkedPR := `{"number":7,"title":"Fix login","url":"https://github.com/octo/demo/pull/7","state":"OPEN"}`
+	unavailable := false
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "viewer") {
+			links++
+			if unavailable {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			fmt.Fprintf(w, `{"data":{"repository":{"issueOrPullRequest":{"__typename":"Issue",
+				"linkedBranches":{"nodes":[]},"closedByPullRequestsReferences":{"nodes":[%s]}}}}}`, linkedPR)
+			return
+		}
+		full++
+		fmt.Fprint(w, `{"data":{"viewer":{"login":"octocat"},"repository":{"nameWithOwner":"octo/demo","issueOrPullRequest":{
+			"__typename":"Issue","number":42,"title":"Login fails","state":"OPEN",
+			"linkedBranches":{"nodes":[]},"closedByPullRequestsReferences":{"nodes":[]}}}}}`)
+	})
+	warned := false
+	c.Cache = &Cache{Dir: t.TempDir(), TTL: 5 * time.Minute, Warn: func(string, ...any) { warned = true }}
+
+	steps := []struct {
+		name        string
+		refresh     bool
+		unavailable bool
+		// wantFull is the number of full queries sent so far
+		wantFull       int
+		wantLinksQuery bool
+		wantPRs        int
+		wantWarning    bool
+	}{
+		{name: "first run fetches everything", wantFull: 1},
+		{name: "fresh issue, current links", wantFull: 1, wantLinksQuery: true, wantPRs: 1},
+		{name: "links unavailable", unavailable: true, wantFull: 1, wantLinksQuery: true, wantWarning: true},
+		{name: "refresh fetches everything", refresh: true, wantFull: 2},
+	}
+	for _, step := range steps {
+		c.Cache.Refresh = step.refresh
+		unavailable = step.unavailable
+		warned = false
+		before := links
+
+		ctx, err := c.GetIssueContext("octo/demo", 42)
+		if err != nil {
+			t.Fatalf("%s: GetIssueContext() error = %v", step.name, err)
+		}
+		if ctx.Title != "Login fails" || len(ctx.LinkedPRs) != step.wantPRs {
+			t.Errorf("%s: GetIssueContext() = %q with %d linked PRs, want %d", step.name, ctx.Title, len(ctx.LinkedPRs), step.wantPRs)
+		}
+		if full != step.wantFull || (links > before) != step.wantLinksQuery {
+			t.Errorf("%s: %d full queries, links queried %v, want %d and %v", step.name, full, links > before, step.wantFull, step.wantLinksQuery)
+		}
+		if warned != step.wantWarning {
+			t.Errorf("%s: warned = %v, want %v", step.name, warned, step.wantWarning)
+		}
+	}
+}
+
+func TestHTTPClientGetIssueContextFallbacks(t *testing.T) {
+	tests := []struct {
+		name string
+		// graphQL answers the query, with or without project items
+		graphQL func(withProjects bool) string
+	}{
+		{
+			name: "no project scope",
+			graphQL: func(withProjects bool) string {
+				if withProjects {
+					return `{"errors":[{"type":"INSUFFICIENT_SCOPES","message":"Your token has not been granted the required scopes to execute this query. The 'title' field requires one of the following scopes: ['read:project'], but your token has only been granted the: ['repo'] scopes."}]}`
+				}
+				return `{"data":{"repository":{"nameWithOwner":"octo/demo","issueOrPullRequest":{"__typename":"Issue","number":42,"title":"Login fails","state":"OPEN"}}}}`
+			},
+		},
+		{
+			name: "schema without sub-issues",
+			graphQL: func(bool) string {
+				return `{"errors":[{"extensions":{"code":"undefinedField"},"message":"Field 'subIssues' doesn't exist on type 'Issue'"}]}`
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/repos/octo/demo/issues/42" {
+					fmt.Fprint(w, `{"number":42,"title":"Login fails","state":"open"}`)
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				fmt.Fprint(w, tt.graphQL(strings.Contains(string(body), "projectItems")))
+			})
+
+			ctx, err := c.GetIssueContext("octo/demo", 42)
+			if err != nil {
+				t.Fatalf("GetIssueContext() error = %v", err)
+			}
+			if ctx.Title != "Login fails" || ctx.Repo != "octo/demo" {
+				t.Errorf("GetIssueContext() = %+v", ctx)
+			}
+			if ctx.Projects != nil {
+				t.Errorf("Projects = %q, want nil as they could not be read", ctx.Projects)
+			}
+		})
+	}
+}
+
+func TestHTTPClientListsPages(t *testing.T) {
+	var srvURL string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"name":"release"}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next", <%s%s?page=2>; rel="last"`, srvURL, r.URL.Path, srvURL, r.URL.Path))
+		fmt.Fprint(w, `[{"name":"main"},{"name":"develop"}]`)
+	})
+	srvURL = strings.TrimSuffix(c.BaseURL, "/")
+
+	var branches []struct {
+		Name string `json:"name"`
+	}
+	if err := getAll(c, "repos/octo/demo/branches", &branches); err != nil {
+		t.Fatalf("getAll() error = %v", err)
+	}
+	var names []string
+	for _, b := range branches {
+		names = append(names, b.Name)
+	}
+	if want := []string{"main", "develop", "release"}; !slices.Equal(names, want) {
+		t.Errorf("getAll() = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,282 @@
+package ghapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Environment variables that select the record and replay transports.
+const (
+	// RecordEnv names a fixture file every API request and response is
+	// written to.
+	RecordEnv = "GH_BUDDY_RECORD"
+	// ReplayEnv names a fixture file responses are served from instead of
+	// the network.
+	ReplayEnv = "GH_BUDDY_REPLAY"
+)
+
+// recordedHeaders are the response headers kept in fixtures. Everything
+// else, and every request header, is dropped so no credentials end up on
+// disk.
+var recordedHeaders = []string{
+	"Content-Type",
+	"ETag",
+	"Link",
+	"Retry-After",
+	"X-Accepted-OAuth-Scopes",
+	"X-GitHub-SSO",
+	"X-OAuth-Scopes",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+}
+
+// redactedFields are JSON fields whose values are replaced with
+// redactedValue in recorded bodies, at any depth.
+var redactedFields = []string{
+	"access_token",
+	"email",
+	"password",
+	"secret",
+	"token",
+}
+
+const redactedValue = "REDACTED"
+
+// Fixture is a recorded sequence of API requests and responses.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response it got.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest identifies a request by method, path with query, and body.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Body   fixtureBody `json:"body,omitempty"`
+}
+
+// RecordedResponse is a sanitized response.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   fixtureBody `json:"body,omitempty"`
+}
+
+// fixtureBody is a request or response body. JSON bodies are stored as JSON
+// so fixtures stay readable and editable; anything else as a string.
+type fixtureBody string
+
+func (b fixtureBody) MarshalJSON() ([]byte, error) {
+	if json.Valid([]byte(b)) && !strings.HasPrefix(strings.TrimSpace(string(b)), `"`) {
+		return []byte(b), nil
+	}
+	return json.Marshal(string(b))
+}
+
+func (b *fixtureBody) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*b = fixtureBody(s)
+		return nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return err
+	}
+	*b = fixtureBody(compact.String())
+	return nil
+}
+
+// Recorder is an http.RoundTripper that writes each exchange to a fixture
+// file. Bodies are kept, with the values of redactedFields replaced, so the
+// file is only readable by its owner.
+type Recorder struct {
+	// Path is the fixture file, rewritten after every request so an
+	// interrupted run still leaves a usable fixture.
+	Path string
+	// Transport sends the requests; nil means http.DefaultTransport.
+	Transport http.RoundTripper
+
+	mu      sync.Mutex
+	fixture Fixture
+}
+
+// RoundTrip sends the request and records it with its response.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for _, name := range recordedHeaders {
+		if v := resp.Header.Values(name); len(v) > 0 {
+			header[name] = v
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, Interaction{
+		Request:  RecordedRequest{Method: req.Method, URL: req.URL.RequestURI(), Body: fixtureBody(redactBody(reqBody))},
+		Response: RecordedResponse{Status: resp.StatusCode, Header: header, Body: fixtureBody(redactBody(respBody))},
+	})
+	data, err := json.MarshalIndent(r.fixture, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(r.Path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+// Replayer is an http.RoundTripper that serves responses from a fixture.
+// Each recorded interaction is served once, in order, to the first request
+// with the same method, URL and body.
+type Replayer struct {
+	mu      sync.Mutex
+	fixture Fixture
+	used    []bool
+}
+
+// LoadReplayer reads a fixture file written by a Recorder.
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &Replayer{fixture: f, used: make([]bool, len(f.Interactions))}, nil
+}
+
+// RoundTrip serves the recorded response for the request.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.fixture.Interactions {
+		if r.used[i] || in.Request.Method != req.Method || in.Request.URL != req.URL.RequestURI() || !sameBody(in.Request.Body, redactBody(body)) {
+			continue
+		}
+		r.used[i] = true
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewBufferString(string(in.Response.Body))),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w for %s %s", errNotRecorded, req.Method, req.URL.RequestURI())
+}
+
+// errNotRecorded is returned when a fixture has no response for a request.
+var errNotRecorded = errors.New("no recorded response")
+
+// redactBody replaces the values of redactedFields in a JSON body. Other
+// bodies, and JSON bodies with nothing to redact, are returned unchanged.
+func redactBody(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if len(body) == 0 || dec.Decode(&v) != nil || !redact(v) {
+		return body
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// redact replaces the values of redactedFields in a decoded JSON value and
+// reports whether it changed anything.
+func redact(v any) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if field != nil && slices.Contains(redactedFields, strings.ToLower(k)) {
+				v[k] = redactedValue
+				changed = true
+				continue
+			}
+			changed = redact(field) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = redact(item) || changed
+		}
+	}
+	return changed
+}
+
+// sameBody reports whether a request body matches a recorded one, ignoring
+// JSON formatting.
+func sameBody(recorded fixtureBody, body []byte) bool {
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		body = compact.Bytes()
+	}
+	return string(recorded) == string(body)
+}
+
+// readBody reads a request or response body and replaces it with a copy
+// that can be read again.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,68 @@
+package ghapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Set-Cookie", "session=secret-cookie")
+		fmt.Fprint(w, `{"number":42,"title":"Login fails","user":{"login":"octocat","email":"octocat@example.com"}}`)
+	})
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	c.HTTP.Transport = &Recorder{Path: path}
+
+	if _, err := c.GetIssue("octo/demo", 42); err != nil {
+		t.Fatalf("GetIssue() while recording error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("fixture permissions = %v, want 0600", perm)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"test-token", "secret-cookie", "octocat@example.com"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("fixture contains %q:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), `"ETag"`) {
+		t.Errorf("fixture lost the ETag header:\n%s", data)
+	}
+
+	replayer, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("LoadReplayer() error = %v", err)
+	}
+	c.HTTP.Transport = replayer
+	issue, err := c.GetIssue("octo/demo", 42)
+	if err != nil {
+		t.Fatalf("GetIssue() while replaying error = %v", err)
+	}
+	if issue.Title != "Login fails" || requests != 1 {
+		t.Errorf("GetIssue() = %+v after %d requests, want the recorded issue after 1", issue, requests)
+	}
+
+	// Each interaction is served once, and missing ones are not retried
+	if _, err := c.GetIssue("octo/demo", 42); !errors.Is(err, errNotRecorded) {
+		t.Errorf("GetIssue() of an unrecorded request error = %v, want errNotRecorded", err)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests sent while replaying, want none", requests-1)
+	}
+}
@@ -0,0 +1,90 @@
+package ghapi
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/url"
+	"time"
+)
+
+const (
+	// maxRetries is how many times a failed idempotent request is retried.
+	maxRetries = 3
+	// baseDelay is the backoff before the first retry; it doubles each time.
+	baseDelay = 500 * time.Millisecond
+	// maxRateLimitWait is the longest to wait for a rate limit to reset
+	// before giving up.
+	maxRateLimitWait = time.Minute
+)
+
+// sleep waits between retries.
+var sleep = time.Sleep
+
+// withRetry runs op, retrying it while it fails with a transient error. Only
+// use it for requests that are safe to repeat.
+func withRetry(op func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || attempt == maxRetries {
+			return err
+		}
+		delay, ok := retryDelay(err, attempt)
+		if !ok {
+			return err
+		}
+		sleep(delay)
+	}
+}
+
+// createPRSafely runs create, retrying transient failures. Before each retry
+// it looks for an open PR for the head branch with find, so a request that
+// succeeded but whose response was lost does not create a duplicate.
+func createPRSafely(create, find func() (*PullRequest, error)) (*PullRequest, error) {
+	for attempt := 0; ; attempt++ {
+		pr, err := create()
+		if err == nil || attempt == maxRetries {
+			return pr, err
+		}
+		delay, ok := retryDelay(err, attempt)
+		if !ok {
+			return pr, err
+		}
+		sleep(delay)
+		if existing, err := find(); err == nil && existing != nil {
+			return existing, nil
+		}
+	}
+}
+
+// retryDelay returns how long to wait before retrying after err, or false
+// when err is not transient. Rate limits are waited out until their reset
+// time, when it is known and near enough; server errors and network
+// failures use exponential backoff with jitter.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	var apiErr *APIError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &apiErr):
+		if apiErr.Kind != ErrRateLimited && apiErr.StatusCode < 500 {
+			return 0, false
+		}
+		if apiErr.Reset.IsZero() {
+			return backoff(attempt), true
+		}
+		wait := time.Until(apiErr.Reset)
+		if wait > maxRateLimitWait {
+			return 0, false
+		}
+		return max(wait, 0) + rand.N(baseDelay), true
+	case errors.As(err, &urlErr) && !errors.Is(err, errNotRecorded):
+		return backoff(attempt), true
+	}
+	return 0, false
+}
+
+// backoff returns the exponential delay for the attempt, randomised to
+// between half and all of it.
+func backoff(attempt int) time.Duration {
+	d := baseDelay << attempt
+	return d/2 + rand.N(d/2+1)
+}
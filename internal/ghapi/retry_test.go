@@ -0,0 +1,160 @@
+package ghapi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	netErr := &url.Error{Op: "Get", URL: "https://api.github.com/", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name     string
+		err      error
+		attempt  int
+		retry    bool
+		min, max time.Duration
+	}{
+		{
+			name: "not found",
+			err:  &APIError{Kind: ErrNotFound, StatusCode: 404},
+		},
+		{
+			name: "validation",
+			err:  &APIError{Kind: ErrValidation, StatusCode: 422},
+		},
+		{
+			name:    "server error, first attempt",
+			err:     &APIError{StatusCode: 502},
+			attempt: 0,
+			retry:   true,
+			min:     baseDelay / 2,
+			max:     baseDelay,
+		},
+		{
+			name:    "server error, third attempt",
+			err:     &APIError{StatusCode: 503},
+			attempt: 2,
+			retry:   true,
+			min:     2 * baseDelay,
+			max:     4 * baseDelay,
+		},
+		{
+			name:    "server error with retry-after",
+			err:     &APIError{StatusCode: 503, Reset: time.Now().Add(10 * time.Second)},
+			attempt: 0,
+			retry:   true,
+			min:     9 * time.Second,
+			max:     10*time.Second + baseDelay,
+		},
+		{
+			name:  "rate limited, reset soon",
+			err:   &APIError{Kind: ErrRateLimited, StatusCode: 403, Reset: time.Now().Add(20 * time.Second)},
+			retry: true,
+			min:   19 * time.Second,
+			max:   20*time.Second + baseDelay,
+		},
+		{
+			name:  "rate limited, reset passed",
+			err:   &APIError{Kind: ErrRateLimited, StatusCode: 403, Reset: time.Now().Add(-time.Second)},
+			retry: true,
+			min:   0,
+			max:   baseDelay,
+		},
+		{
+			name: "rate limited, reset too far",
+			err:  &APIError{Kind: ErrRateLimited, StatusCode: 403, Reset: time.Now().Add(time.Hour)},
+		},
+		{
+			name:  "rate limited, reset unknown",
+			err:   &APIError{Kind: ErrRateLimited, StatusCode: 403},
+			retry: true,
+			min:   baseDelay / 2,
+			max:   baseDelay,
+		},
+		{
+			name:    "wrapped network failure",
+			err:     fmt.Errorf("failed to fetch issue #1: %w", netErr),
+			attempt: 1,
+			retry:   true,
+			min:     baseDelay,
+			max:     2 * baseDelay,
+		},
+		{
+			name: "missing fixture response",
+			err:  &url.Error{Op: "Post", URL: "https://api.github.com/graphql", Err: errNotRecorded},
+		},
+		{
+			name: "other error",
+			err:  errors.New("failed to parse response"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := retryDelay(tt.err, tt.attempt)
+			if retry != tt.retry {
+				t.Fatalf("retryDelay() retry = %v, want %v", retry, tt.retry)
+			}
+			if delay < tt.min || delay > tt.max {
+				t.Errorf("retryDelay() = %v, want between %v and %v", delay, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	t.Cleanup(func() { sleep = time.Sleep })
+
+	calls := 0
+	err := withRetry(func() error {
+		calls++
+		if calls < 3 {
+			return &APIError{StatusCode: 502}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry() = %v, want nil", err)
+	}
+	if calls != 3 || len(slept) != 2 {
+		t.Errorf("withRetry() made %d calls and %d waits, want 3 and 2", calls, len(slept))
+	}
+
+	calls = 0
+	notFound := &APIError{Kind: ErrNotFound, StatusCode: 404}
+	err = withRetry(func() error {
+		calls++
+		return notFound
+	})
+	if err != notFound || calls != 1 {
+		t.Errorf("withRetry() = %v after %d calls, want %v after 1", err, calls, notFound)
+	}
+}
+
+func TestCreatePRSafely(t *testing.T) {
+	sleep = func(time.Duration) {}
+	t.Cleanup(func() { sleep = time.Sleep })
+
+	// The first request timed out after GitHub created the PR
+	existing := &PullRequest{Number: 7}
+	creates := 0
+	pr, err := createPRSafely(
+		func() (*PullRequest, error) {
+			creates++
+			return nil, &url.Error{Op: "Post", Err: errors.New("timeout")}
+		},
+		func() (*PullRequest, error) { return existing, nil },
+	)
+	if err != nil || pr != existing {
+		t.Fatalf("createPRSafely() = %v, %v, want the existing PR", pr, err)
+	}
+	if creates != 1 {
+		t.Errorf("createPRSafely() sent %d create requests, want 1", creates)
+	}
+}
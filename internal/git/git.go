@@ -2,25 +2,36 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
 	"strings"
 )
 
 // CurrentBranch returns the name of the current git branch.
 func CurrentBranch() (string, error) {
-	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	out, err := run("rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
 	}
-	return strings.TrimSpace(string(out)), nil
+	return strings.TrimSpace(out), nil
+}
+
+// TopLevel returns the absolute path of the root of the current work tree.
+func TopLevel() (string, error) {
+	out, err := run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository root: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// IsRepository reports whether the current directory is inside a git work tree.
+func IsRepository() bool {
+	_, err := run("rev-parse", "--is-inside-work-tree")
+	return err == nil
 }
 
 // CreateAndCheckout creates a new branch from the current HEAD and checks it out.
 func CreateAndCheckout(branchName string) error {
-	cmd := exec.Command("git", "checkout", "-b", branchName)
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	if err := cmd.Run(); err != nil {
+	if _, err := run("checkout", "-b", branchName); err != nil {
 		return fmt.Errorf("failed to create branch %q: %w", branchName, err)
 	}
 	return nil
@@ -28,36 +39,137 @@ func CreateAndCheckout(branchName string) error {
 
 // PushBranch pushes the given branch to the remote, setting the upstream.
 func PushBranch(remote, branch string) error {
-	cmd := exec.Command("git", "push", "-u", remote, branch)
-	if err := cmd.Run(); err != nil {
+	if offline {
+		return pushOffline(remote, branch)
+	}
+	if _, err := run("push", "-u", remote, branch); err != nil {
 		return fmt.Errorf("failed to push branch %q to %q: %w", branch, remote, err)
 	}
 	return nil
 }
 
+// ForcePushBranch pushes the given branch to the remote with
+// --force-with-lease, replacing the remote branch only if it still points
+// where the remote-tracking ref says.
+func ForcePushBranch(remote, branch string) error {
+	if offline {
+		return pushOffline(remote, branch)
+	}
+	if _, err := run("push", "--force-with-lease", "-u", remote, branch); err != nil {
+		return fmt.Errorf("failed to force-push branch %q to %q: %w", branch, remote, err)
+	}
+	return nil
+}
+
+// PushState compares a local branch with its copy on a remote.
+type PushState struct {
+	// Pushed reports whether the branch exists on the remote.
+	Pushed bool
+	// Ahead counts the commits only in the local branch, Behind the commits
+	// only in the remote one.
+	Ahead, Behind int
+}
+
+// Diverged reports whether both sides have commits the other lacks, as after
+// a rebase of a pushed branch.
+func (s PushState) Diverged() bool {
+	return s.Ahead > 0 && s.Behind > 0
+}
+
+// BranchPushState fetches the branch from the remote and compares it with
+// the local branch.
+func BranchPushState(remote, branch string) (PushState, error) {
+	exists, err := RemoteBranchExists(remote, branch)
+	if err != nil || !exists {
+		return PushState{}, err
+	}
+	if !offline {
+		if _, err := run("fetch", remote, branch); err != nil {
+			return PushState{}, fmt.Errorf("failed to fetch %s/%s: %w", remote, branch, err)
+		}
+	}
+
+	ref := fmt.Sprintf("%s/%s", remote, branch)
+	out, err := run("rev-list", "--left-right", "--count", branch+"..."+ref)
+	if err != nil {
+		return PushState{}, fmt.Errorf("failed to compare %q with %q: %w", branch, ref, err)
+	}
+	state := PushState{Pushed: true}
+	if _, err := fmt.Sscan(out, &state.Ahead, &state.Behind); err != nil {
+		return PushState{}, fmt.Errorf("failed to compare %q with %q: unexpected output %q", branch, ref, out)
+	}
+	return state, nil
+}
+
+// CountCommits returns the number of commits reachable from to but not from
+// from.
+func CountCommits(from, to string) (int, error) {
+	out, err := run("rev-list", "--count", from+".."+to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits between %q and %q: %w", from, to, err)
+	}
+	var n int
+	if _, err := fmt.Sscan(out, &n); err != nil {
+		return 0, fmt.Errorf("failed to count commits between %q and %q: unexpected output %q", from, to, out)
+	}
+	return n, nil
+}
+
 // HasUncommittedChanges returns true if the working tree has uncommitted changes.
 func HasUncommittedChanges() (bool, error) {
-	out, err := exec.Command("git", "status", "--porcelain").Output()
+	out, err := run("status", "--porcelain")
 	if err != nil {
 		return false, fmt.Errorf("failed to check git status: %w", err)
 	}
-	return len(strings.TrimSpace(string(out))) > 0, nil
+	return len(strings.TrimSpace(out)) > 0, nil
+}
+
+// Stash stashes the uncommitted changes, untracked files included.
+func Stash(message string) error {
+	if _, err := run("stash", "push", "--include-untracked", "--message", message); err != nil {
+		return fmt.Errorf("failed to stash changes: %w", err)
+	}
+	return nil
+}
+
+// StashPop re-applies the latest stash and drops it. When re-applying
+// conflicts, the stash is kept.
+func StashPop() error {
+	if _, err := run("stash", "pop"); err != nil {
+		return fmt.Errorf("failed to re-apply stashed changes: %w", err)
+	}
+	return nil
+}
+
+// ConflictedFiles returns the paths with unresolved merge conflicts.
+func ConflictedFiles() ([]string, error) {
+	out, err := run("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicted files: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
 }
 
-// DefaultBranch returns the default branch of the repository (main or master).
-func DefaultBranch() (string, error) {
-	out, err := exec.Command("git", "symbolic-ref", "refs/remotes/origin/HEAD", "--short").Output()
+// DefaultBranch returns the default branch of the remote (main or master).
+func DefaultBranch(remote string) (string, error) {
+	out, err := run("symbolic-ref", "refs/remotes/"+remote+"/HEAD", "--short")
 	if err != nil {
 		// Fallback: try common names
 		for _, name := range []string{"main", "master"} {
-			if err2 := exec.Command("git", "rev-parse", "--verify", "origin/"+name).Run(); err2 == nil {
+			if _, err2 := run("rev-parse", "--verify", remote+"/"+name); err2 == nil {
 				return name, nil
 			}
 		}
 		return "", fmt.Errorf("failed to determine default branch: %w", err)
 	}
-	branch := strings.TrimSpace(string(out))
-	// Remove "origin/" prefix
+	branch := strings.TrimSpace(out)
+	// Remove "<remote>/" prefix
 	parts := strings.SplitN(branch, "/", 2)
 	if len(parts) == 2 {
 		return parts[1], nil
@@ -65,59 +177,100 @@ func DefaultBranch() (string, error) {
 	return branch, nil
 }
 
-// RepoSlug returns the "owner/repo" slug parsed from the origin remote URL.
-func RepoSlug() (string, error) {
-	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+// Repo identifies a GitHub repository.
+type Repo struct {
+	Host  string
+	Owner string
+	Name  string
+}
+
+// Slug returns the "owner/repo" slug.
+func (r Repo) Slug() string {
+	return r.Owner + "/" + r.Name
+}
+
+// String returns the full "host/owner/repo" identity.
+func (r Repo) String() string {
+	return r.Host + "/" + r.Slug()
+}
+
+// Remotes returns the names of the configured remotes.
+func Remotes() ([]string, error) {
+	out, err := run("remote")
 	if err != nil {
-		return "", fmt.Errorf("failed to get origin remote URL: %w", err)
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
 	}
-	url := strings.TrimSpace(string(out))
-	return parseRepoSlug(url)
+	return strings.Fields(out), nil
 }
 
-func parseRepoSlug(rawURL string) (string, error) {
-	rawURL = strings.TrimSuffix(rawURL, ".git")
-
-	// SSH: git@github.com:owner/repo
-	if strings.HasPrefix(rawURL, "git@") {
-		parts := strings.SplitN(rawURL, ":", 2)
-		if len(parts) == 2 {
-			return parts[1], nil
-		}
+// RemoteRepo returns the repository the remote URL points at.
+func RemoteRepo(remote string) (Repo, error) {
+	out, err := run("remote", "get-url", remote)
+	if err != nil {
+		return Repo{}, fmt.Errorf("failed to get %s remote URL: %w", remote, err)
 	}
+	url := strings.TrimSpace(out)
+	return parseRepoURL(url)
+}
 
-	// HTTPS: https://github.com/owner/repo
-	for _, prefix := range []string{"https://", "http://"} {
-		if strings.HasPrefix(rawURL, prefix) {
-			trimmed := strings.TrimPrefix(rawURL, prefix)
-			// Remove host
-			idx := strings.Index(trimmed, "/")
-			if idx >= 0 {
-				return trimmed[idx+1:], nil
-			}
+// parseRepoURL parses the host, owner and name from a remote URL such as
+// "git@host:owner/repo.git", "ssh://git@host:22/owner/repo" or
+// "https://host/owner/repo".
+func parseRepoURL(rawURL string) (Repo, error) {
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(rawURL, "/"), ".git")
+
+	var host, path string
+	if scheme, rest, ok := strings.Cut(trimmed, "://"); ok {
+		// URL: https://host/owner/repo, ssh://git@host:22/owner/repo
+		switch scheme {
+		case "https", "http", "ssh", "git":
+		default:
+			return Repo{}, fmt.Errorf("unable to parse repo from URL: %s", rawURL)
+		}
+		host, path, _ = strings.Cut(rest, "/")
+		host = host[strings.LastIndex(host, "@")+1:]
+		host, _, _ = strings.Cut(host, ":")
+	} else {
+		// scp-like SSH: git@host:owner/repo
+		host, path, ok = strings.Cut(trimmed, ":")
+		if !ok {
+			return Repo{}, fmt.Errorf("unable to parse repo from URL: %s", rawURL)
 		}
+		host = host[strings.LastIndex(host, "@")+1:]
 	}
 
-	return "", fmt.Errorf("unable to parse repo slug from URL: %s", rawURL)
+	parts := strings.Split(path, "/")
+	if host == "" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Repo{}, fmt.Errorf("unable to parse repo from URL: %s", rawURL)
+	}
+	return Repo{Host: strings.ToLower(host), Owner: parts[0], Name: parts[1]}, nil
 }
 
 // FetchLatest fetches the latest changes from the remote.
 func FetchLatest(remote string) error {
-	cmd := exec.Command("git", "fetch", remote)
-	if err := cmd.Run(); err != nil {
+	if offline {
+		return nil
+	}
+	if _, err := run("fetch", remote); err != nil {
 		return fmt.Errorf("failed to fetch from %q: %w", remote, err)
 	}
 	return nil
 }
 
-
 // SetUpstreamTracking configures the local branch to track the remote branch.
+// The upstream is written to the branch config, as `git push -u` does, so
+// it does not depend on the remote-tracking ref being there yet.
 func SetUpstreamTracking(remote, branch string) error {
-	if err := exec.Command("git", "fetch", remote, branch).Run(); err != nil {
-		return fmt.Errorf("failed to fetch %s/%s: %w", remote, branch, err)
+	if !offline {
+		if _, err := run("fetch", remote, branch); err != nil {
+			return fmt.Errorf("failed to fetch %s/%s: %w", remote, branch, err)
+		}
 	}
 	upstream := fmt.Sprintf("%s/%s", remote, branch)
-	if err := exec.Command("git", "branch", "--set-upstream-to="+upstream, branch).Run(); err != nil {
+	if err := SetBranchConfig(branch, "remote", remote); err != nil {
+		return fmt.Errorf("failed to set upstream tracking to %s: %w", upstream, err)
+	}
+	if err := SetBranchConfig(branch, "merge", "refs/heads/"+branch); err != nil {
 		return fmt.Errorf("failed to set upstream tracking to %s: %w", upstream, err)
 	}
 	return nil
@@ -126,15 +279,79 @@ func SetUpstreamTracking(remote, branch string) error {
 // CreateBranchFrom creates a new branch from a given base branch and checks it out.
 func CreateBranchFrom(branchName, baseBranch, remote string) error {
 	// Fetch the specific base branch to ensure the remote ref is up to date.
-	fetchCmd := exec.Command("git", "fetch", remote, baseBranch)
-	if out, err := fetchCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to fetch %s/%s: %w\n%s", remote, baseBranch, err, strings.TrimSpace(string(out)))
+	if !offline {
+		if _, err := run("fetch", remote, baseBranch); err != nil {
+			return fmt.Errorf("failed to fetch %s/%s: %w", remote, baseBranch, err)
+		}
 	}
 
 	ref := fmt.Sprintf("%s/%s", remote, baseBranch)
-	cmd := exec.Command("git", "checkout", "-b", branchName, ref)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to create branch %q from %q: %w\n%s", branchName, ref, err, strings.TrimSpace(string(out)))
+	if _, err := run("checkout", "-b", branchName, ref); err != nil {
+		return fmt.Errorf("failed to create branch %q from %q: %w", branchName, ref, err)
+	}
+	return nil
+}
+
+// LocalBranchExists reports whether a local branch with the given name exists.
+func LocalBranchExists(branch string) bool {
+	_, err := run("rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// RemoteBranchExists reports whether the branch exists on the remote.
+func RemoteBranchExists(remote, branch string) (bool, error) {
+	if offline {
+		return remoteBranchExistsOffline(remote, branch), nil
+	}
+	_, err := run("ls-remote", "--exit-code", "--heads", remote, "refs/heads/"+branch)
+	if err == nil {
+		return true, nil
+	}
+	// ls-remote exits with 2 when no matching ref is found
+	if exitCode(err) == 2 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to query %s for branch %q: %w", remote, branch, err)
+}
+
+// CheckoutBranch checks out an existing branch. If it only exists on the
+// remote, a local branch tracking it is created.
+func CheckoutBranch(branch, remote string) error {
+	if LocalBranchExists(branch) {
+		if _, err := run("checkout", branch); err != nil {
+			return fmt.Errorf("failed to check out %q: %w", branch, err)
+		}
+		return nil
+	}
+
+	if !offline {
+		if _, err := run("fetch", remote, branch); err != nil {
+			return fmt.Errorf("failed to fetch %s/%s: %w", remote, branch, err)
+		}
+	}
+	ref := fmt.Sprintf("%s/%s", remote, branch)
+	if _, err := run("checkout", "-b", branch, "--track", ref); err != nil {
+		return fmt.Errorf("failed to check out %q: %w", ref, err)
 	}
 	return nil
 }
+
+// SetBranchConfig stores a value under branch.<branch>.<key> in the
+// repository's git config.
+func SetBranchConfig(branch, key, value string) error {
+	name := fmt.Sprintf("branch.%s.%s", branch, key)
+	if _, err := run("config", name, value); err != nil {
+		return fmt.Errorf("failed to set %s: %w", name, err)
+	}
+	return nil
+}
+
+// BranchConfig returns the value stored under branch.<branch>.<key>, or an
+// empty string if it is not set.
+func BranchConfig(branch, key string) string {
+	out, err := run("config", "--get", fmt.Sprintf("branch.%s.%s", branch, key))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
@@ -0,0 +1,224 @@
+package git
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useRunner makes the helpers run git through r for the rest of the test.
+func useRunner(t *testing.T, r Runner) {
+	t.Helper()
+	previous := runner
+	SetRunner(r)
+	t.Cleanup(func() { SetRunner(previous) })
+}
+
+// failure is a failed git command with the given exit status and stderr.
+func failure(args []string, code int, stderr string) error {
+	return &Error{Args: args, ExitCode: code, Stderr: stderr, Err: errors.New("exit status")}
+}
+
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    Repo
+		wantErr bool
+	}{
+		{url: "https://github.com/octo/demo.git", want: Repo{Host: "github.com", Owner: "octo", Name: "demo"}},
+		{url: "https://github.com/octo/demo/", want: Repo{Host: "github.com", Owner: "octo", Name: "demo"}},
+		{url: "https://user@GitHub.com/octo/demo", want: Repo{Host: "github.com", Owner: "octo", Name: "demo"}},
+		{url: "git@github.com:octo/demo.git", want: Repo{Host: "github.com", Owner: "octo", Name: "demo"}},
+		{url: "ssh://git@github.example.com:2222/octo/demo.git", want: Repo{Host: "github.example.com", Owner: "octo", Name: "demo"}},
+		{url: "git@github-work:acme/api.git", want: Repo{Host: "github-work", Owner: "acme", Name: "api"}},
+		{url: "file:///srv/git/demo.git", wantErr: true},
+		{url: "https://github.com/octo", wantErr: true},
+		{url: "https://github.com/octo/demo/tree/main", wantErr: true},
+		{url: "/srv/git/demo.git", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := parseRepoURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRepoURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRepoURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoteBranchExists(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{name: "found", want: true},
+		{name: "not found", err: failure(nil, 2, ""), want: false},
+		{name: "unreachable", err: failure(nil, 128, "fatal: unable to access"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &DryRunner{Output: func([]string) (string, error) { return "", tt.err }}
+			useRunner(t, r)
+
+			got, err := RemoteBranchExists("origin", "feature/x")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoteBranchExists() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RemoteBranchExists() = %v, want %v", got, tt.want)
+			}
+			want := []string{"ls-remote", "--exit-code", "--heads", "origin", "refs/heads/feature/x"}
+			if cmds := r.Commands(); len(cmds) != 1 || !slices.Equal(cmds[0], want) {
+				t.Errorf("commands = %q, want [%q]", cmds, want)
+			}
+		})
+	}
+}
+
+func TestBranchPushState(t *testing.T) {
+	tests := []struct {
+		name     string
+		exists   bool
+		counts   string
+		want     PushState
+		diverged bool
+	}{
+		{name: "not pushed", exists: false, want: PushState{}},
+		{name: "up to date", exists: true, counts: "0\t0\n", want: PushState{Pushed: true}},
+		{name: "ahead", exists: true, counts: "2\t0\n", want: PushState{Pushed: true, Ahead: 2}},
+		{name: "diverged", exists: true, counts: "1\t3\n", want: PushState{Pushed: true, Ahead: 1, Behind: 3}, diverged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRunner(t, &DryRunner{Output: func(args []string) (string, error) {
+				switch args[0] {
+				case "ls-remote":
+					if !tt.exists {
+						return "", failure(args, 2, "")
+					}
+					return "abc123\trefs/heads/topic\n", nil
+				case "rev-list":
+					return tt.counts, nil
+				}
+				return "", nil
+			}})
+
+			got, err := BranchPushState("origin", "topic")
+			if err != nil {
+				t.Fatalf("BranchPushState() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BranchPushState() = %+v, want %+v", got, tt.want)
+			}
+			if got.Diverged() != tt.diverged {
+				t.Errorf("Diverged() = %v, want %v", got.Diverged(), tt.diverged)
+			}
+		})
+	}
+}
+
+func TestPushBranchKeepsStderr(t *testing.T) {
+	useRunner(t, &DryRunner{Output: func(args []string) (string, error) {
+		return "", failure(args, 1, "! [rejected] topic -> topic (non-fast-forward)")
+	}})
+
+	err := PushBranch("origin", "topic")
+	if err == nil || !strings.Contains(err.Error(), "non-fast-forward") {
+		t.Errorf("PushBranch() error = %v, want it to include git's stderr", err)
+	}
+	var gitErr *Error
+	if !errors.As(err, &gitErr) || gitErr.ExitCode != 1 {
+		t.Errorf("PushBranch() error = %#v, want a *git.Error with exit status 1", err)
+	}
+}
+
+func TestCreateBranchFrom(t *testing.T) {
+	r := &DryRunner{}
+	useRunner(t, r)
+
+	if err := CreateBranchFrom("feature/GH-1-x", "main", "upstream"); err != nil {
+		t.Fatalf("CreateBranchFrom() error = %v", err)
+	}
+	want := [][]string{
+		{"fetch", "upstream", "main"},
+		{"checkout", "-b", "feature/GH-1-x", "upstream/main"},
+	}
+	if got := r.Commands(); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		output func(args []string) (string, error)
+		want   string
+	}{
+		{
+			name:   "remote HEAD",
+			output: func([]string) (string, error) { return "origin/develop\n", nil },
+			want:   "develop",
+		},
+		{
+			name: "no remote HEAD, master",
+			output: func(args []string) (string, error) {
+				if args[0] == "symbolic-ref" || args[len(args)-1] == "origin/main" {
+					return "", failure(args, 128, "")
+				}
+				return "", nil
+			},
+			want: "master",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRunner(t, &DryRunner{Output: tt.output})
+			got, err := DefaultBranch("origin")
+			if err != nil {
+				t.Fatalf("DefaultBranch() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DefaultBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktrees(t *testing.T) {
+	porcelain := `worktree /src/demo
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /src/demo-GH-42
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/GH-42-login
+
+worktree /src/demo-review
+HEAD 3333333333333333333333333333333333333333
+detached
+`
+	useRunner(t, &DryRunner{Output: func([]string) (string, error) { return porcelain, nil }})
+
+	got, err := Worktrees()
+	if err != nil {
+		t.Fatalf("Worktrees() error = %v", err)
+	}
+	want := []Worktree{
+		{Path: "/src/demo", Branch: "main"},
+		{Path: "/src/demo-GH-42", Branch: "feature/GH-42-login"},
+		{Path: "/src/demo-review"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Worktrees() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package git
+
+import "fmt"
+
+// offline makes the helpers that talk to a remote simulate it against its
+// remote-tracking branches, so no command touches the network: fetches
+// change nothing, pushes move the remote-tracking branch and set it as
+// upstream, and looking a branch up on the remote checks the remote-tracking
+// branch. Local commands run as usual.
+var offline bool
+
+// SetOffline turns the simulation of remotes on or off. It is meant for
+// replayed runs, which must not reach GitHub through git either.
+func SetOffline(on bool) {
+	offline = on
+}
+
+// pushOffline simulates `git push -u remote branch`.
+func pushOffline(remote, branch string) error {
+	ref := fmt.Sprintf("refs/remotes/%s/%s", remote, branch)
+	if _, err := run("update-ref", ref, "refs/heads/"+branch); err != nil {
+		return fmt.Errorf("failed to push branch %q to %q: %w", branch, remote, err)
+	}
+	return SetUpstreamTracking(remote, branch)
+}
+
+// remoteBranchExistsOffline reports whether the remote-tracking branch of
+// branch on remote exists.
+func remoteBranchExistsOffline(remote, branch string) bool {
+	ref := fmt.Sprintf("refs/remotes/%s/%s", remote, branch)
+	_, err := run("rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
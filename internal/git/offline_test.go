@@ -0,0 +1,41 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestOffline(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"-c", "user.name=Buddy", "-c", "user.email=buddy@example.com", "commit", "--quiet", "--allow-empty", "--message", "Initial commit"},
+		{"remote", "add", "origin", "https://github.com/octo/demo.git"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	SetOffline(true)
+	t.Cleanup(func() { SetOffline(false) })
+
+	// The remote URL is unreachable, so any of these reaching it would fail
+	if err := FetchLatest("origin"); err != nil {
+		t.Fatalf("FetchLatest() error = %v", err)
+	}
+	if exists, err := RemoteBranchExists("origin", "main"); err != nil || exists {
+		t.Fatalf("RemoteBranchExists() before push = %v, %v, want false", exists, err)
+	}
+	if err := PushBranch("origin", "main"); err != nil {
+		t.Fatalf("PushBranch() error = %v", err)
+	}
+	if exists, err := RemoteBranchExists("origin", "main"); err != nil || !exists {
+		t.Errorf("RemoteBranchExists() after push = %v, %v, want true", exists, err)
+	}
+	if got := BranchConfig("main", "merge"); got != "refs/heads/main" {
+		t.Errorf("upstream of main = %q, want refs/heads/main", got)
+	}
+}
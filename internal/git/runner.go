@@ -0,0 +1,139 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runner runs git commands. Every helper of this package goes through the
+// runner set with SetRunner.
+type Runner interface {
+	// Run runs git with the given arguments and returns its standard
+	// output. A failed command returns an *Error.
+	Run(args ...string) (string, error)
+}
+
+// Error is a failed git command.
+type Error struct {
+	Args []string
+	// ExitCode is the exit status of git, or -1 if it could not be run.
+	ExitCode int
+	// Stderr is what git printed on standard error, trimmed.
+	Stderr string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Stderr == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v\n%s", e.Err, e.Stderr)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// exitCode returns the exit status of a failed command, or -1 if err is not
+// a git error.
+func exitCode(err error) int {
+	var gitErr *Error
+	if errors.As(err, &gitErr) {
+		return gitErr.ExitCode
+	}
+	return -1
+}
+
+// ExecRunner runs git as a subprocess.
+type ExecRunner struct {
+	// Dir is the working directory; empty means the current directory.
+	Dir string
+}
+
+// Run runs git, capturing its standard output and standard error.
+func (r ExecRunner) Run(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		gitErr := &Error{Args: args, ExitCode: -1, Stderr: strings.TrimSpace(stderr.String()), Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			gitErr.ExitCode = exitErr.ExitCode()
+		}
+		return stdout.String(), gitErr
+	}
+	return stdout.String(), nil
+}
+
+// DryRunner records commands instead of running them.
+type DryRunner struct {
+	// Output returns the result of a command. nil means every command
+	// succeeds with no output.
+	Output func(args []string) (string, error)
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+// Run records the command and returns what Output gives for it.
+func (r *DryRunner) Run(args ...string) (string, error) {
+	r.mu.Lock()
+	r.commands = append(r.commands, append([]string(nil), args...))
+	r.mu.Unlock()
+	if r.Output == nil {
+		return "", nil
+	}
+	return r.Output(args)
+}
+
+// Commands returns the recorded commands, in order.
+func (r *DryRunner) Commands() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.commands...)
+}
+
+// TraceRunner logs each command with its duration before returning the
+// result of the wrapped runner.
+type TraceRunner struct {
+	Runner Runner
+	// Out receives one line per command.
+	Out io.Writer
+}
+
+// Run runs the command with the wrapped runner and logs it.
+func (r TraceRunner) Run(args ...string) (string, error) {
+	start := time.Now()
+	out, err := r.Runner.Run(args...)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	status := ""
+	if err != nil {
+		status = ", failed"
+		if code := exitCode(err); code >= 0 {
+			status = fmt.Sprintf(", exit %d", code)
+		}
+	}
+	fmt.Fprintf(r.Out, "+ git %s (%s%s)\n", strings.Join(args, " "), elapsed, status)
+	return out, err
+}
+
+var runner Runner = ExecRunner{}
+
+// SetRunner makes the helpers of this package run git through r.
+func SetRunner(r Runner) {
+	runner = r
+}
+
+// run runs git through the current runner.
+func run(args ...string) (string, error) {
+	return runner.Run(args...)
+}
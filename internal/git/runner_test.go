@@ -0,0 +1,56 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestExecRunnerError(t *testing.T) {
+	_, err := ExecRunner{Dir: t.TempDir()}.Run("rev-parse", "--verify", "refs/heads/missing")
+
+	var gitErr *Error
+	if !errors.As(err, &gitErr) {
+		t.Fatalf("Run() error = %v, want a *git.Error", err)
+	}
+	if gitErr.ExitCode <= 0 {
+		t.Errorf("ExitCode = %d, want git's non-zero status", gitErr.ExitCode)
+	}
+	if gitErr.Stderr == "" || !strings.Contains(err.Error(), gitErr.Stderr) {
+		t.Errorf("Error() = %q, want it to include stderr %q", err, gitErr.Stderr)
+	}
+}
+
+func TestTraceRunner(t *testing.T) {
+	var out bytes.Buffer
+	dry := &DryRunner{Output: func(args []string) (string, error) {
+		if args[0] == "push" {
+			return "", failure(args, 1, "rejected")
+		}
+		return "main\n", nil
+	}}
+	r := TraceRunner{Runner: dry, Out: &out}
+
+	if got, _ := r.Run("rev-parse", "--abbrev-ref", "HEAD"); got != "main\n" {
+		t.Errorf("Run() = %q, want the wrapped runner's output", got)
+	}
+	if _, err := r.Run("push", "origin", "main"); err == nil {
+		t.Error("Run() error = nil, want the wrapped runner's error")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	patterns := []string{
+		`^\+ git rev-parse --abbrev-ref HEAD \(\d+m?s\)$`,
+		`^\+ git push origin main \(\d+m?s, exit 1\)$`,
+	}
+	if len(lines) != len(patterns) {
+		t.Fatalf("trace = %q, want %d lines", lines, len(patterns))
+	}
+	for i, p := range patterns {
+		if !regexp.MustCompile(p).MatchString(lines[i]) {
+			t.Errorf("trace line %d = %q, want a match for %s", i, lines[i], p)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Worktree is a working tree attached to the repository.
+type Worktree struct {
+	Path string
+	// Branch is the checked out branch; empty when HEAD is detached.
+	Branch string
+	Bare   bool
+}
+
+// Worktrees lists the working trees of the repository, the main one first.
+func Worktrees() ([]Worktree, error) {
+	out, err := run("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	var trees []Worktree
+	for _, line := range strings.Split(out, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch {
+		case key == "worktree":
+			trees = append(trees, Worktree{Path: value})
+		case len(trees) == 0:
+		case key == "branch":
+			trees[len(trees)-1].Branch = strings.TrimPrefix(value, "refs/heads/")
+		case key == "bare":
+			trees[len(trees)-1].Bare = true
+		}
+	}
+	if len(trees) == 0 {
+		return nil, fmt.Errorf("failed to list worktrees: no worktree found")
+	}
+	return trees, nil
+}
+
+// AddWorktree fetches the base branch and creates a new branch from it,
+// checked out in a new working tree at path.
+func AddWorktree(path, branchName, baseBranch, remote string) error {
+	if !offline {
+		if _, err := run("fetch", remote, baseBranch); err != nil {
+			return fmt.Errorf("failed to fetch %s/%s: %w", remote, baseBranch, err)
+		}
+	}
+
+	ref := fmt.Sprintf("%s/%s", remote, baseBranch)
+	if _, err := run("worktree", "add", "-b", branchName, path, ref); err != nil {
+		return fmt.Errorf("failed to create worktree for %q from %q: %w", branchName, ref, err)
+	}
+	return nil
+}
+
+// AddWorktreeForBranch checks out an existing branch in a new working tree
+// at path. If it only exists on the remote, a local branch tracking it is
+// created.
+func AddWorktreeForBranch(path, branch, remote string) error {
+	if LocalBranchExists(branch) {
+		if _, err := run("worktree", "add", path, branch); err != nil {
+			return fmt.Errorf("failed to create worktree for %q: %w", branch, err)
+		}
+		return nil
+	}
+
+	if !offline {
+		if _, err := run("fetch", remote, branch); err != nil {
+			return fmt.Errorf("failed to fetch %s/%s: %w", remote, branch, err)
+		}
+	}
+	ref := fmt.Sprintf("%s/%s", remote, branch)
+	if _, err := run("worktree", "add", "--track", "-b", branch, path, ref); err != nil {
+		return fmt.Errorf("failed to create worktree for %q: %w", ref, err)
+	}
+	return nil
+}
+
+// RemoveWorktree removes the working tree at path. It fails when the tree
+// has uncommitted changes.
+func RemoveWorktree(path string) error {
+	if _, err := run("worktree", "remove", path); err != nil {
+		return fmt.Errorf("failed to remove worktree %s: %w", path, err)
+	}
+	return nil
+}
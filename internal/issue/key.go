@@ -0,0 +1,147 @@
+package issue
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Tracker names the system an issue lives in.
+type Tracker string
+
+// GitHub is the default tracker. Any other tracker is treated as an external
+// system with "PROJECT-123" style keys, such as Jira.
+const GitHub Tracker = "github"
+
+// Key identifies an issue in a tracker.
+type Key struct {
+	Tracker Tracker
+	// Project is the project key of an external tracker (e.g. "PAY"), or the
+	// "owner/repo" of a GitHub issue in another repository. It is empty for
+	// GitHub issues of the current repository.
+	Project string
+	// ID is the issue number within the project.
+	ID string
+	// Host is the GitHub host of an issue given by URL, and empty otherwise.
+	Host string
+}
+
+// GitHubKey returns the key of a GitHub issue in the current repository.
+func GitHubKey(number int) Key {
+	return Key{Tracker: GitHub, ID: strconv.Itoa(number)}
+}
+
+// Repo returns the "owner/repo" of a GitHub issue in another repository, or
+// an empty string for issues of the current repository.
+func (k Key) Repo() string {
+	if !k.IsGitHub() {
+		return ""
+	}
+	return k.Project
+}
+
+// IsZero reports whether k is the zero Key.
+func (k Key) IsZero() bool {
+	return k.ID == ""
+}
+
+// IsGitHub reports whether k refers to a GitHub issue.
+func (k Key) IsGitHub() bool {
+	return k.Tracker == GitHub
+}
+
+// Number returns the numeric ID, or 0 if the ID is not a number.
+func (k Key) Number() int {
+	n, err := strconv.Atoi(k.ID)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// String returns the key as users write it: "#42" for GitHub issues,
+// "acme/product#42" for GitHub issues in another repository and "PAY-881"
+// for external trackers.
+func (k Key) String() string {
+	if k.IsGitHub() {
+		return k.Project + "#" + k.ID
+	}
+	return k.Project + "-" + k.ID
+}
+
+// Scheme describes how issue keys are written for a repository.
+type Scheme struct {
+	Tracker Tracker
+	// Project is the default project for bare numbers in an external tracker.
+	Project string
+	// URL is a link template for external issues, with "{key}" replaced by
+	// the issue key (e.g. "https://jira.example.com/browse/{key}").
+	URL string
+}
+
+// DefaultScheme returns the scheme for GitHub issues.
+func DefaultScheme() Scheme {
+	return Scheme{Tracker: GitHub}
+}
+
+// IsGitHub reports whether the scheme uses GitHub issues.
+func (s Scheme) IsGitHub() bool {
+	return s.Tracker == GitHub || s.Tracker == ""
+}
+
+var (
+	numberRegex      = regexp.MustCompile(`^#?(\d+)$`)
+	projectKeyRegex  = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)-(\d+)$`)
+	validProjectName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	// crossRepoRegex matches "owner/repo#123".
+	crossRepoRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9_.-]+)#(\d+)$`)
+	// issueURLRegex matches issue URLs such as
+	// "https://github.com/owner/repo/issues/123".
+	issueURLRegex = regexp.MustCompile(`^https?://([^/]+)/([^/]+/[^/]+)/issues/(\d+)/?(?:[?#].*)?$`)
+)
+
+// ValidProject reports whether s can be used as an external project key.
+func ValidProject(s string) bool {
+	return validProjectName.MatchString(s)
+}
+
+// ParseKey parses an issue reference typed by the user. Bare numbers ("42"
+// or "#42") refer to a GitHub issue, or to the default project of an
+// external tracker. "PAY-881" refers to an issue of an external tracker.
+// "acme/product#123" and issue URLs refer to a GitHub issue of that
+// repository, whatever the tracker; URLs also keep their host.
+func (s Scheme) ParseKey(ref string) (Key, error) {
+	ref = strings.TrimSpace(ref)
+	if m := crossRepoRegex.FindStringSubmatch(ref); m != nil {
+		return Key{Tracker: GitHub, Project: m[1], ID: m[2]}, nil
+	}
+	if m := issueURLRegex.FindStringSubmatch(ref); m != nil {
+		return Key{Tracker: GitHub, Project: m[2], ID: m[3], Host: strings.ToLower(m[1])}, nil
+	}
+	if m := numberRegex.FindStringSubmatch(ref); m != nil {
+		if s.IsGitHub() {
+			return Key{Tracker: GitHub, ID: m[1]}, nil
+		}
+		if s.Project == "" {
+			return Key{}, fmt.Errorf("issue %q has no project; use the PROJECT-%s form", ref, m[1])
+		}
+		return Key{Tracker: s.Tracker, Project: strings.ToUpper(s.Project), ID: m[1]}, nil
+	}
+	if m := projectKeyRegex.FindStringSubmatch(ref); m != nil {
+		if s.IsGitHub() {
+			return Key{}, fmt.Errorf("issue %q looks like an external tracker key, but the tracker is github", ref)
+		}
+		return Key{Tracker: s.Tracker, Project: strings.ToUpper(m[1]), ID: m[2]}, nil
+	}
+	return Key{}, fmt.Errorf("invalid issue reference %q", ref)
+}
+
+// Link returns the web URL of an external issue, or an empty string if the
+// scheme has no URL template or the key is a GitHub issue.
+func (s Scheme) Link(k Key) string {
+	if k.IsGitHub() || s.URL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(s.URL, "{key}", k.String())
+}
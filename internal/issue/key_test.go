@@ -0,0 +1,95 @@
+package issue
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	jira := Scheme{Tracker: "jira", Project: "pay"}
+
+	tests := []struct {
+		name    string
+		scheme  Scheme
+		ref     string
+		want    Key
+		wantErr string
+	}{
+		{name: "number", scheme: DefaultScheme(), ref: "42", want: Key{Tracker: GitHub, ID: "42"}},
+		{name: "hash number", scheme: DefaultScheme(), ref: " #42 ", want: Key{Tracker: GitHub, ID: "42"}},
+		{name: "empty tracker is github", scheme: Scheme{}, ref: "7", want: Key{Tracker: GitHub, ID: "7"}},
+		{name: "other repository", scheme: DefaultScheme(), ref: "acme/product#123", want: Key{Tracker: GitHub, Project: "acme/product", ID: "123"}},
+		{
+			name:   "issue URL",
+			scheme: DefaultScheme(),
+			ref:    "https://GitHub.example.com/acme/product/issues/123#issuecomment-1",
+			want:   Key{Tracker: GitHub, Project: "acme/product", ID: "123", Host: "github.example.com"},
+		},
+		{name: "external key", scheme: jira, ref: "pay-881", want: Key{Tracker: "jira", Project: "PAY", ID: "881"}},
+		{name: "external number uses default project", scheme: jira, ref: "881", want: Key{Tracker: "jira", Project: "PAY", ID: "881"}},
+		{name: "GitHub issue with an external tracker", scheme: jira, ref: "acme/product#5", want: Key{Tracker: GitHub, Project: "acme/product", ID: "5"}},
+		{name: "external number without project", scheme: Scheme{Tracker: "jira"}, ref: "881", wantErr: `issue "881" has no project; use the PROJECT-881 form`},
+		{name: "external key with github", scheme: DefaultScheme(), ref: "PAY-881", wantErr: "looks like an external tracker key, but the tracker is github"},
+		{name: "pull request URL", scheme: DefaultScheme(), ref: "https://github.com/acme/product/pull/5", wantErr: "invalid issue reference"},
+		{name: "garbage", scheme: DefaultScheme(), ref: "login bug", wantErr: `invalid issue reference "login bug"`},
+		{name: "empty", scheme: DefaultScheme(), ref: "", wantErr: "invalid issue reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.scheme.ParseKey(tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseKey(%q) error = %v, want it to contain %q", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKey(%q) error = %v", tt.ref, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseKey(%q) = %+v, want %+v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyString(t *testing.T) {
+	tests := []struct {
+		key  Key
+		want string
+	}{
+		{GitHubKey(42), "#42"},
+		{Key{Tracker: GitHub, Project: "acme/product", ID: "42"}, "acme/product#42"},
+		{Key{Tracker: "jira", Project: "PAY", ID: "881"}, "PAY-881"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.key.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSchemeLink(t *testing.T) {
+	s := Scheme{Tracker: "jira", Project: "PAY", URL: "https://jira.example.com/browse/{key}"}
+
+	tests := []struct {
+		name   string
+		scheme Scheme
+		key    Key
+		want   string
+	}{
+		{name: "external issue", scheme: s, key: Key{Tracker: "jira", Project: "PAY", ID: "881"}, want: "https://jira.example.com/browse/PAY-881"},
+		{name: "GitHub issue", scheme: s, key: GitHubKey(42)},
+		{name: "no URL template", scheme: Scheme{Tracker: "jira"}, key: Key{Tracker: "jira", Project: "PAY", ID: "881"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scheme.Link(tt.key); got != tt.want {
+				t.Errorf("Link() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -53,3 +53,26 @@ func SelectWithDefault(message string, options []string, defaultIdx int) (int, e
 	}
 	return -1, nil
 }
+
+// MultiSelect asks the user to select any number of options.
+// Returns the indexes of the selected options in display order.
+func MultiSelect(message string, options []string) ([]int, error) {
+	selected, err := pterm.DefaultInteractiveMultiselect.
+		WithOptions(options).
+		WithMaxHeight(10).
+		Show(message)
+	if err != nil {
+		return nil, err
+	}
+	chosen := make(map[string]bool, len(selected))
+	for _, s := range selected {
+		chosen[s] = true
+	}
+	var result []int
+	for i, opt := range options {
+		if chosen[opt] {
+			result = append(result, i)
+		}
+	}
+	return result, nil
+}
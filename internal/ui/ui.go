@@ -42,10 +42,11 @@ func Header(title string) {
 		Println(title)
 }
 
-// IssuePanel renders a nice box with issue details.
-func IssuePanel(number int, title string) {
+// IssuePanel renders a nice box with issue details. ref is the issue
+// reference as shown to users, e.g. "#42" or "PAY-881".
+func IssuePanel(ref, title string) {
 	content := pterm.Sprintf("  %s %s",
-		pterm.FgLightCyan.Sprint(fmt.Sprintf("#%d", number)),
+		pterm.FgLightCyan.Sprint(ref),
 		pterm.FgLightWhite.Sprint(title),
 	)
 	pterm.DefaultBox.
@@ -103,3 +104,11 @@ func StartSpinner(label string) (*pterm.SpinnerPrinter, error) {
 		WithStyle(pterm.NewStyle(pterm.FgLightCyan)).
 		Start(label)
 }
+
+// Table renders rows under a header row.
+func Table(header []string, rows [][]string) {
+	_ = pterm.DefaultTable.
+		WithHasHeader(true).
+		WithData(append([][]string{header}, rows...)).
+		Render()
+}